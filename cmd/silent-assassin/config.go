@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var validateClient bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Checks silent assassin configuration",
+	Long:  ``,
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "validates the configuration file and lists every invalid value",
+
+	Long: `Validates the configuration file read by the server, or by the client with --client.
+Exits with 1 and lists every invalid value if the configuration is not valid.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		validate := validateServerConfig
+		if validateClient {
+			validate = validateClientConfig
+		}
+
+		if _, err := readConfig(cfgFile, validate); err != nil {
+			printConfigErrors(cfgFile, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Configuration %s is valid\n", cfgFile)
+	},
+}
+
+func init() {
+	validateCmd.Flags().BoolVar(&validateClient, "client", false, "validate the values read by the client instead of the server")
+	configCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(configCmd)
+}
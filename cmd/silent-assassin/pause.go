@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/pause"
+	"github.com/spf13/cobra"
+)
+
+var pauseUntil string
+var pauseFor time.Duration
+var pauseUser string
+var pauseReason string
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "pauses the Killer and the Shifter",
+
+	Long: `Pauses the node disruptions of the Killer and the Shifter of every server replica, until --until or
+for --for, or until resumed when neither is set. The Spotter keeps scheduling the kills and the preempted
+nodes are still drained. The leader notifies the pause and the work it defers once it sees the pause.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var until *time.Time
+		if pauseUntil != "" {
+			t, err := time.Parse(time.RFC3339, pauseUntil)
+			if err != nil {
+				exitWithError(fmt.Errorf("invalid --until %s, the time has to be in RFC3339 format", pauseUntil))
+			}
+			until = &t
+		} else if pauseFor > 0 {
+			t := time.Now().Add(pauseFor)
+			until = &t
+		}
+
+		ps, stop := newPauseService()
+		defer stop()
+		s, err := ps.Pause(until, pauseUser, pauseReason)
+		if err != nil {
+			exitWithError(err)
+		}
+		fmt.Printf("Disruptions %s\n", s)
+	},
+}
+
+var pauseStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "prints whether the Killer and the Shifter are paused",
+
+	Long: ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		ps, stop := newPauseService()
+		defer stop()
+		s, err := ps.Status()
+		if err != nil {
+			exitWithError(err)
+		}
+		if s.Paused && !s.Active {
+			fmt.Printf("Disruptions not paused, the pause ended at %s\n", s.Until.Format(time.RFC1123Z))
+			return
+		}
+		fmt.Printf("Disruptions %s\n", s.State)
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "resumes the Killer and the Shifter",
+
+	Long: `Ends the pause of the node disruptions. The leader notifies the work deferred during the pause on its next poll.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ps, stop := newPauseService()
+		defer stop()
+		if err := ps.Resume(); err != nil {
+			exitWithError(err)
+		}
+		fmt.Println("Disruptions resumed")
+	},
+}
+
+//newPauseService returns a PauseService writing the pause ConfigMap directly, so the disruptions can be
+//paused while no server replica is reachable. stop stops its notifier.
+func newPauseService() (pause.PauseService, func()) {
+	configProvider := loadConfig(cfgFile, func(config.IProvider) error { return nil })
+	zapLogger := logger.Init(configProvider)
+	ns := notifier.NewNotificationService(configProvider, zapLogger)
+
+	wg := &sync.WaitGroup{}
+	ctx, cancelFn := context.WithCancel(context.Background())
+	wg.Add(1)
+	go ns.Start(ctx, wg)
+
+	ps := pause.NewPauseService(configProvider, zapLogger, k8s.NewClient(configProvider, zapLogger), ns)
+	return ps, func() {
+		cancelFn()
+		wg.Wait()
+	}
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(1)
+}
+
+func init() {
+	pauseCmd.Flags().StringVar(&pauseUntil, "until", "", "end of the pause in RFC3339 format, like 2020-11-12T06:00:00+05:30")
+	pauseCmd.Flags().DurationVar(&pauseFor, "for", 0, "duration of the pause, like 2h30m, ignored when --until is set")
+	pauseCmd.Flags().StringVar(&pauseUser, "user", os.Getenv("USER"), "user pausing the disruptions")
+	pauseCmd.Flags().StringVar(&pauseReason, "reason", "", "reason of the pause")
+	pauseCmd.AddCommand(pauseStatusCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/roppenlabs/silent-assassin/pkg/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var cfgFile string
@@ -26,6 +29,23 @@ func init() {
 
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "./config/application.yaml", "config file (default is ./config/application.yaml)")
+
+	//A set flag overrides the SA_ environment variable and the config file value of its key.
+	rootCmd.PersistentFlags().String("label-selectors", "", "label selectors of the nodes, overrides "+strings.ToUpper(config.NodeSelectors))
+	rootCmd.PersistentFlags().String("log-level", "", "debug | info | warn | error, overrides "+strings.ToUpper(config.LogLevel))
+	rootCmd.PersistentFlags().String("run-mode", "", "InCluster | OutCluster, overrides "+strings.ToUpper(config.KubernetesRunMode))
+	rootCmd.PersistentFlags().String("server-host", "", "URL of the server called by the client, overrides "+strings.ToUpper(config.ServerHost))
+}
+
+//configFlags returns the flags overriding configuration keys, by key.
+func configFlags() map[string]*pflag.Flag {
+	flags := rootCmd.PersistentFlags()
+	return map[string]*pflag.Flag{
+		config.NodeSelectors:     flags.Lookup("label-selectors"),
+		config.LogLevel:          flags.Lookup("log-level"),
+		config.KubernetesRunMode: flags.Lookup("run-mode"),
+		config.ServerHost:        flags.Lookup("server-host"),
+	}
 }
 
 func initConfig() {
@@ -7,7 +7,6 @@ import (
 	"sync"
 	"syscall"
 
-	"github.com/roppenlabs/silent-assassin/pkg/config"
 	"github.com/roppenlabs/silent-assassin/pkg/informer"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
 	"github.com/spf13/cobra"
@@ -24,7 +23,7 @@ var clientCmd = &cobra.Command{
 		wg := &sync.WaitGroup{}
 		ctx, cancelFn := context.WithCancel(context.Background())
 
-		configProvider := config.Init(cfgFile)
+		configProvider := loadConfig(cfgFile, validateClientConfig)
 		zapLogger := logger.Init(configProvider)
 
 		pns := informer.NewInformerService(zapLogger, configProvider)
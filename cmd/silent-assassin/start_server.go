@@ -2,23 +2,31 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
 	"github.com/roppenlabs/silent-assassin/pkg/gcloud"
 	"github.com/roppenlabs/silent-assassin/pkg/httpserver"
 	"github.com/roppenlabs/silent-assassin/pkg/k8s"
 	"github.com/roppenlabs/silent-assassin/pkg/killer"
+	"github.com/roppenlabs/silent-assassin/pkg/leader"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
 	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/pause"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
 	"github.com/roppenlabs/silent-assassin/pkg/shifter"
 	"github.com/roppenlabs/silent-assassin/pkg/spotter"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/cache"
 )
 
+var dryRun bool
+
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "starts silent assassin server",
@@ -30,31 +38,89 @@ var serverCmd = &cobra.Command{
 		wg := &sync.WaitGroup{}
 		ctx, cancelFn := context.WithCancel(context.Background())
 
-		configProvider := config.Init(cfgFile)
+		configProvider := loadConfig(cfgFile, validateServerConfig)
 		zapLogger := logger.Init(configProvider)
-		kubeClient := k8s.NewClient(configProvider, zapLogger)
-
-		gcloudClient := gcloud.NewClient(kubeClient)
-
 		ns := notifier.NewNotificationService(configProvider, zapLogger)
 		wg.Add(1)
 		go ns.Start(ctx, wg)
 
-		ss := spotter.NewSpotterService(configProvider, zapLogger, kubeClient, ns)
+		// The configuration file is read again when it changes, the last valid configuration is kept.
 		wg.Add(1)
-		go ss.Start(ctx, wg)
+		go func() {
+			defer wg.Done()
+			err := configProvider.Watch(ctx, validateServerConfig, func(changed bool, err error) {
+				if err != nil {
+					zapLogger.Error(fmt.Sprintf("Rejected the changed configuration, keeping the last valid one: %s", err.Error()))
+					ns.Error(config.EventConfig, fmt.Sprintf("Rejected the changed configuration, keeping the last valid one: %s", err.Error()))
+				} else if changed {
+					zapLogger.Info("Reloaded the changed configuration")
+					ns.Info(config.EventConfig, "Reloaded the changed configuration")
+				}
+			})
+			if err != nil {
+				zapLogger.Error(fmt.Sprintf("Error watching the configuration file: %s", err.Error()))
+			}
+		}()
 
-		ks := killer.NewKillerService(configProvider, zapLogger, kubeClient, gcloudClient, ns)
-		wg.Add(1)
-		go ks.Start(ctx, wg)
+		k8sClient := k8s.NewClient(configProvider, zapLogger)
+		cachedClient := k8s.NewCachedClient(k8sClient, zapLogger)
+		if err := cachedClient.Start(ctx); err != nil {
+			panic(err.Error())
+		}
+
+		var kubeClient k8s.IKubernetesClient = cachedClient
+		gcloudClient := gcloud.NewClient(configProvider, kubeClient)
+
+		if dryRun || configProvider.GetBool(config.DryRun) {
+			kubeClient = k8s.NewDryRunClient(kubeClient, zapLogger, ns)
+			gcloudClient = gcloud.NewDryRunClient(gcloudClient, zapLogger, ns)
+		}
 
+		// Every replica caches the policy resources, only the leader writes their status.
+		var policySource policy.ISource
+		var pw *policy.Watcher
+		if configProvider.GetBool(config.PolicyCRDEnabled) {
+			pw = policy.NewWatcher(configProvider, zapLogger, kubeClient, k8sClient.Dynamic, ns)
+			if err := pw.Sync(ctx); err != nil {
+				panic(err.Error())
+			}
+			policySource = pw
+		}
+
+		ss := spotter.NewSpotterService(configProvider, zapLogger, kubeClient, ns, policySource)
+		cachedClient.AddNodeEventHandler(cache.ResourceEventHandlerFuncs{AddFunc: ss.OnNodeAdd})
+
+		// Every drain, on expiry, shift or preemption, goes through the killer and acquires the coordinator.
+		dc := disruption.NewCoordinator(configProvider, zapLogger)
+		// The Killer and the Shifter skip their work while paused, the pause is set through the API of any replica.
+		ps := pause.NewPauseService(configProvider, zapLogger, kubeClient, ns)
+		ks := killer.NewKillerService(configProvider, zapLogger, kubeClient, gcloudClient, ns, dc, policySource, ps)
+
+		// Spotter, Killer and Shifter disrupt nodes, so only the leader runs them when there are multiple replicas.
+		services := []leader.IService{ss, ks}
+		var shifterStatus shifter.IStatusReporter
 		if configProvider.GetBool(config.ShifterEnabled) {
-			shs := shifter.NewShifterService(configProvider, zapLogger, kubeClient, gcloudClient, ns, ks)
+			shs := shifter.NewShifterService(configProvider, zapLogger, kubeClient, gcloudClient, ns, ks, policySource, ps)
+			services = append(services, shs)
+			shifterStatus = shs
+		}
+		if pw != nil {
+			services = append(services, pw)
+		}
+
+		if configProvider.GetBool(config.LeaderElectionEnabled) {
+			es := leader.NewElectorService(configProvider, zapLogger, k8sClient.CoordinationV1(), ns, services...)
 			wg.Add(1)
-			go shs.Start(ctx, wg)
+			go es.Start(ctx, wg)
+		} else {
+			for _, service := range services {
+				wg.Add(1)
+				go service.Start(ctx, wg)
+			}
 		}
 
-		server := httpserver.New(configProvider, zapLogger, ks)
+		// The HTTP server runs on every replica, so preemptions are handled during a leader election too.
+		server := httpserver.New(configProvider, zapLogger, ks, kubeClient, ns, shifterStatus, ps)
 		wg.Add(1)
 		go server.Start(ctx, wg)
 
@@ -68,5 +134,6 @@ var serverCmd = &cobra.Command{
 }
 
 func init() {
+	serverCmd.Flags().BoolVar(&dryRun, "dry-run", false, "log and notify the node disruptions instead of performing them")
 	startCmd.AddCommand(serverCmd)
 }
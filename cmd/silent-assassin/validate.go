@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
+)
+
+//validateServerConfig returns the config.Errors of every invalid value read by the server, nil if there are none.
+//The server validates its configuration at startup and on every reload, an invalid reload is rejected.
+func validateServerConfig(cp config.IProvider) error {
+	c, err := config.Read(cp)
+	if err != nil {
+		return config.Errors{err}
+	}
+
+	var errs config.Errors
+	if err := c.ValidateServer(); err != nil {
+		errs = append(errs, err.(config.Errors)...)
+	}
+	if err := logger.ValidateLevel(cp.GetString(config.LogLevel)); err != nil {
+		errs = append(errs, err)
+	}
+	//The policies default to the Spotter and Killer values, so they are only checked once these are valid.
+	if len(errs) == 0 {
+		if _, err := policy.Load(cp, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+//validateClientConfig returns the config.Errors of every invalid value read by the client, nil if there are none.
+func validateClientConfig(cp config.IProvider) error {
+	c, err := config.Read(cp)
+	if err != nil {
+		return config.Errors{err}
+	}
+
+	var errs config.Errors
+	if err := c.ValidateClient(); err != nil {
+		errs = append(errs, err.(config.Errors)...)
+	}
+	if err := logger.ValidateLevel(cp.GetString(config.LogLevel)); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+//readConfig reads the configuration file, with the environment and the flags overriding it, and validates it.
+func readConfig(cfgFile string, validate func(config.IProvider) error) (*config.Provider, error) {
+	cp, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := cp.BindFlags(configFlags()); err != nil {
+		return nil, err
+	}
+	return cp, validate(cp)
+}
+
+//loadConfig reads and validates the configuration file. It lists every error and exits if the file cannot be
+//read or is invalid, so a bad value stops the process before any component starts.
+func loadConfig(cfgFile string, validate func(config.IProvider) error) *config.Provider {
+	cp, err := readConfig(cfgFile, validate)
+	if err != nil {
+		printConfigErrors(cfgFile, err)
+		os.Exit(1)
+	}
+	return cp
+}
+
+//printConfigErrors lists the errors of the configuration on stderr, one per line.
+func printConfigErrors(cfgFile string, err error) {
+	fmt.Fprintf(os.Stderr, "Invalid configuration %s:\n", cfgFile)
+	errs, ok := err.(config.Errors)
+	if !ok {
+		errs = config.Errors{err}
+	}
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "  - %s\n", e)
+	}
+}
@@ -0,0 +1,99 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+)
+
+//dateLayout is the layout of the blackout dates, YYYY-MM-DD.
+const dateLayout = "2006-01-02"
+
+//ParseWeekday parses the english name of a day of the week, like Saturday or saturday.
+func ParseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), strings.TrimSpace(name)) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid day of the week %s", name)
+}
+
+//ParseDayIntervals parses the day of the week rules, which map the name of a day to its
+//whitelist intervals in HH:MM-HH:MM format separated by commas.
+func ParseDayIntervals(days map[string]string) (map[time.Weekday][]string, error) {
+	dayIntervals := make(map[time.Weekday][]string)
+	for name, intervals := range days {
+		day, err := ParseWeekday(name)
+		if err != nil {
+			return dayIntervals, err
+		}
+		dayIntervals[day] = strings.Split(intervals, config.CommaSeparater)
+	}
+	return dayIntervals, nil
+}
+
+//Blackout holds the dates on which no node may be killed.
+type Blackout map[string]bool
+
+//Contains returns true if the date of t, in the location of t, is a blackout date.
+func (b Blackout) Contains(t time.Time) bool {
+	return b[t.Format(dateLayout)]
+}
+
+//LoadBlackout reads the blackout dates listed in the configuration, in the blackout file
+//and in the values of the blackout ConfigMap. The file and the ConfigMap are optional.
+func LoadBlackout(cp config.IProvider, kc k8s.IKubernetesClient) (Blackout, error) {
+	blackout := Blackout{}
+
+	if err := blackout.add(strings.Join(cp.GetStringSlice(config.BlackoutDates), "\n")); err != nil {
+		return blackout, fmt.Errorf("invalid %s: %s", config.BlackoutDates, err.Error())
+	}
+
+	if file := cp.GetString(config.BlackoutFile); file != "" {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return blackout, fmt.Errorf("error reading blackout file %s: %s", file, err.Error())
+		}
+		if err := blackout.add(string(content)); err != nil {
+			return blackout, fmt.Errorf("invalid blackout file %s: %s", file, err.Error())
+		}
+	}
+
+	if name := cp.GetString(config.BlackoutConfigMap); name != "" {
+		namespace := cp.GetString(config.BlackoutNamespace)
+		configMap, err := kc.GetConfigMap(name, namespace)
+		if err != nil {
+			return blackout, fmt.Errorf("error reading blackout ConfigMap %s/%s: %s", namespace, name, err.Error())
+		}
+		for key, content := range configMap.Data {
+			if err := blackout.add(content); err != nil {
+				return blackout, fmt.Errorf("invalid blackout ConfigMap %s/%s key %s: %s", namespace, name, key, err.Error())
+			}
+		}
+	}
+
+	return blackout, nil
+}
+
+//add adds the dates in content, one date per line. Blank lines and lines starting with # are ignored.
+func (b Blackout) add(content string) error {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		date, err := time.Parse(dateLayout, line)
+		if err != nil {
+			return err
+		}
+		b[date.Format(dateLayout)] = true
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,84 @@
+package calendar
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+)
+
+type CalendarTestSuite struct {
+	suite.Suite
+	configMock *config.ProviderMock
+	k8sMock    *k8s.K8sClientMock
+}
+
+func (ct *CalendarTestSuite) SetupTest() {
+	ct.configMock = new(config.ProviderMock)
+	ct.k8sMock = new(k8s.K8sClientMock)
+}
+
+func (ct *CalendarTestSuite) TestShouldParseDayIntervals() {
+	dayIntervals, err := ParseDayIntervals(map[string]string{"saturday": "00:00-08:00,13:00-17:00", "Sunday": "00:00-10:00"})
+	assert.Nil(ct.T(), err)
+	assert.Equal(ct.T(), []string{"00:00-08:00", "13:00-17:00"}, dayIntervals[time.Saturday])
+	assert.Equal(ct.T(), []string{"00:00-10:00"}, dayIntervals[time.Sunday])
+
+	_, err = ParseDayIntervals(map[string]string{"weekend": "00:00-10:00"})
+	assert.NotNil(ct.T(), err)
+}
+
+func (ct *CalendarTestSuite) TestShouldLoadBlackoutFromConfigFileAndConfigMap() {
+	f, err := ioutil.TempFile("", "blackout-*.txt")
+	if err != nil {
+		ct.T().Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("# Diwali sale\n2020-11-13\n\n2020-11-14\n")
+	f.Close()
+
+	ct.configMock.On("GetStringSlice", config.BlackoutDates).Return([]string{"2020-10-16"})
+	ct.configMock.On("GetString", config.BlackoutFile).Return(f.Name())
+	ct.configMock.On("GetString", config.BlackoutConfigMap).Return("blackout-dates")
+	ct.configMock.On("GetString", config.BlackoutNamespace).Return("default")
+	ct.k8sMock.On("GetConfigMap", "blackout-dates", "default").Return(v1.ConfigMap{Data: map[string]string{"dates": "2020-12-25"}}, nil)
+
+	blackout, err := LoadBlackout(ct.configMock, ct.k8sMock)
+	assert.Nil(ct.T(), err)
+
+	for _, date := range []string{"2020-10-16", "2020-11-13", "2020-11-14", "2020-12-25"} {
+		day, _ := time.Parse(dateLayout, date)
+		assert.True(ct.T(), blackout.Contains(day), date+" should be a blackout date")
+	}
+	day, _ := time.Parse(dateLayout, "2020-11-15")
+	assert.False(ct.T(), blackout.Contains(day))
+}
+
+func (ct *CalendarTestSuite) TestShouldFailOnInvalidBlackout() {
+	ct.configMock.On("GetStringSlice", config.BlackoutDates).Return([]string{"16-10-2020"})
+
+	_, err := LoadBlackout(ct.configMock, ct.k8sMock)
+	assert.NotNil(ct.T(), err)
+}
+
+func (ct *CalendarTestSuite) TestShouldFailWhenConfigMapCannotBeRead() {
+	ct.configMock.On("GetStringSlice", config.BlackoutDates).Return([]string{})
+	ct.configMock.On("GetString", config.BlackoutFile).Return("")
+	ct.configMock.On("GetString", config.BlackoutConfigMap).Return("blackout-dates")
+	ct.configMock.On("GetString", config.BlackoutNamespace).Return("default")
+	ct.k8sMock.On("GetConfigMap", "blackout-dates", "default").Return(v1.ConfigMap{}, errors.New("forbidden"))
+
+	_, err := LoadBlackout(ct.configMock, ct.k8sMock)
+	assert.NotNil(ct.T(), err)
+}
+
+func TestCalendarTestSuite(t *testing.T) {
+	suite.Run(t, new(CalendarTestSuite))
+}
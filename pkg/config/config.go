@@ -1,14 +1,23 @@
 package config
 
 import (
+	"errors"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+//Provider serves the values of the configuration file. The file can be read again with Reload,
+//which swaps the values served and calls the change handlers.
 type Provider struct {
-	*viper.Viper
+	file     string
+	flags    map[string]*pflag.Flag
+	mu       sync.RWMutex
+	viper    *viper.Viper
+	handlers []func()
 }
 
 type IProvider interface {
@@ -30,80 +39,128 @@ type IProvider interface {
 	GetStringMapStringSlice(key string) map[string][]string
 	GetSizeInBytes(key string) uint
 	SplitStringToSlice(key string, sep string) []string
+	UnmarshalKey(key string, rawVal interface{}) error
+	OnChange(handler func())
 }
 
 var fetcher Provider
 
 func Init(cfgFile string) *Provider {
+	cp, err := Load(cfgFile)
+	if err != nil {
+		panic(err)
+	}
+	return cp
+}
 
-	v := viper.New()
+//Load reads the configuration file, it returns an error instead of panicking like Init.
+func Load(cfgFile string) (*Provider, error) {
 	if cfgFile == "" {
-		panic("Config file path was not provided!")
+		return nil, errors.New("config file path was not provided")
 	}
 
-	v.SetConfigFile(cfgFile)
+	v, err := read(cfgFile, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := v.ReadInConfig(); err != nil {
+	return &Provider{file: cfgFile, viper: v}, nil
+}
 
-		panic(err)
+//BindFlags makes the flags, by configuration key, override the environment and the file when they are set.
+//The flags stay bound when the configuration is reloaded.
+func (f *Provider) BindFlags(flags map[string]*pflag.Flag) error {
+	v, err := read(f.file, flags)
+	if err != nil {
+		return err
 	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flags = flags
+	f.viper = v
+	return nil
+}
 
-	return &Provider{Viper: v}
+//read reads the configuration file into a new viper, with the defaults, the environment and the flags.
+func read(cfgFile string, flags map[string]*pflag.Flag) (*viper.Viper, error) {
+	v, err := newViper(cfgFile, flags)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
 
+//get returns the viper holding the values currently served.
+func (f *Provider) get() *viper.Viper {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.viper
 }
 
 func (f *Provider) GetString(key string) string {
-	return f.Viper.GetString(key)
+	return f.get().GetString(key)
 }
 func (f *Provider) GetBool(key string) bool {
-	return f.Viper.GetBool(key)
+	return f.get().GetBool(key)
 }
 func (f *Provider) GetInt(key string) int {
-	return f.Viper.GetInt(key)
+	return f.get().GetInt(key)
 }
 func (f *Provider) GetInt32(key string) int32 {
-	return f.Viper.GetInt32(key)
+	return f.get().GetInt32(key)
 }
 func (f *Provider) GetInt64(key string) int64 {
-	return f.Viper.GetInt64(key)
+	return f.get().GetInt64(key)
 }
 func (f *Provider) GetUint(key string) uint {
-	return f.Viper.GetUint(key)
+	return f.get().GetUint(key)
 }
 func (f *Provider) GetUint32(key string) uint32 {
-	return f.Viper.GetUint32(key)
+	return f.get().GetUint32(key)
 }
 func (f *Provider) GetUint64(key string) uint64 {
-	return f.Viper.GetUint64(key)
+	return f.get().GetUint64(key)
 }
 func (f *Provider) GetFloat64(key string) float64 {
-	return f.Viper.GetFloat64(key)
+	return f.get().GetFloat64(key)
 }
 func (f *Provider) GetTime(key string) time.Time {
-	return f.Viper.GetTime(key)
+	return f.get().GetTime(key)
 }
 func (f *Provider) GetDuration(key string) time.Duration {
-	return f.Viper.GetDuration(key)
+	return f.get().GetDuration(key)
 }
 func (f *Provider) GetIntSlice(key string) []int {
-	return f.Viper.GetIntSlice(key)
+	return f.get().GetIntSlice(key)
 }
 func (f *Provider) GetStringSlice(key string) []string {
-	return f.Viper.GetStringSlice(key)
+	return f.get().GetStringSlice(key)
 }
 func (f *Provider) GetStringMap(key string) map[string]interface{} {
-	return f.Viper.GetStringMap(key)
+	return f.get().GetStringMap(key)
 }
 func (f *Provider) GetStringMapString(key string) map[string]string {
-	return f.Viper.GetStringMapString(key)
+	return f.get().GetStringMapString(key)
 }
 func (f *Provider) GetStringMapStringSlice(key string) map[string][]string {
-	return f.Viper.GetStringMapStringSlice(key)
+	return f.get().GetStringMapStringSlice(key)
 }
 func (f *Provider) GetSizeInBytes(key string) uint {
-	return f.Viper.GetSizeInBytes(key)
+	return f.get().GetSizeInBytes(key)
 }
 func (f *Provider) SplitStringToSlice(key string, sep string) []string {
-	str := f.Viper.GetString(key)
+	str := f.get().GetString(key)
 	return strings.Split(str, sep)
 }
+//UnmarshalKey decodes the value of a key, with the values of its nested keys coming from the flags,
+//the environment and the defaults as well as from the file.
+func (f *Provider) UnmarshalKey(key string, rawVal interface{}) error {
+	settings := viper.New()
+	if err := settings.MergeConfigMap(f.get().AllSettings()); err != nil {
+		return err
+	}
+	return settings.UnmarshalKey(key, rawVal)
+}
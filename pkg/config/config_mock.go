@@ -84,3 +84,11 @@ func (f *ProviderMock) SplitStringToSlice(key string, sep string) []string {
 	args := f.Called(key, sep)
 	return args.Get(0).([]string)
 }
+
+func (f *ProviderMock) UnmarshalKey(key string, rawVal interface{}) error {
+	args := f.Called(key, rawVal)
+	return args.Error(0)
+}
+
+func (f *ProviderMock) OnChange(handler func()) {
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 const KubernetesRunMode = "kubernetes.run_mode"
 
 const ServerListenHost = "server_listen_host"
@@ -7,23 +9,82 @@ const ServerHost = "server_host"
 const ServerPort = "server_port"
 const Metrics = "/metrics"
 
+const DryRun = "dry_run"
+const DryRunMarker = "[DRY RUN]"
+
 const NodeSelectors = "label_selectors"
 const ExpiryTimeAnnotation = "silent-assassin/expiry-time"
+const PolicyAnnotation = "silent-assassin/policy"
+const SkipExpiryAnnotation = "silent-assassin/skip-expiry"
+const PostponeKillUntilAnnotation = "silent-assassin/postpone-kill-until"
+const SkipShiftAnnotation = "silent-assassin/skip-shift"
+const ProtectPodAnnotation = "silent-assassin/protect"
+const DrainFailuresAnnotation = "silent-assassin/drain-failures"
+const StateAnnotation = "silent-assassin/state"
+const NodeLifetime = 24 * time.Hour
+const NodePoolNameLabel = "cloud.google.com/gke-nodepool"
+const ZoneLabel = "failure-domain.beta.kubernetes.io/zone"
+const HostnameLabel = "kubernetes.io/hostname"
+
+const Policies = "policies"
+const PolicyCRDEnabled = "policy_crd.enabled"
+const PolicyCRDStatusIntervalMs = "policy_crd.status_interval_ms"
 
 const SpotterPollIntervalMs = "spotter.poll_interval_ms"
 
 const SpotterWhiteListIntervalHours = "spotter.white_list_interval_hours"
+const SpotterWhiteListTimezone = "spotter.white_list_timezone"
+const SpotterWhiteListDayIntervalHours = "spotter.white_list_day_interval_hours"
+const SpotterSlotMinutes = "spotter.slot_minutes"
+const SpotterMaxKillsPerSlotPerNodePool = "spotter.max_kills_per_slot_per_nodepool"
+const SpotterMaxKillsPerSlotPerZone = "spotter.max_kills_per_slot_per_zone"
 
 const KillerPollIntervalMs = "killer.poll_interval_ms"
 const KillerDrainingTimeoutWhenNodeExpiredMs = "killer.draining_timeout_when_node_expired_ms"
 const KillerDrainingTimeoutWhenNodePreemptedMs = "killer.draining_timeout_when_node_preempted_ms"
+const KillerEvictionRetries = "killer.eviction_retries"
+const KillerEvictionBackoffMs = "killer.eviction_backoff_ms"
+const KillerSurgeMode = "killer.surge.mode"
+const KillerSurgeTimeoutMs = "killer.surge.timeout_ms"
+const KillerSurgePlaceholderNamespace = "killer.surge.placeholder_namespace"
+const KillerSurgePlaceholderImage = "killer.surge.placeholder_image"
+const KillerHealthGateTimeoutMs = "killer.health_gate_timeout_ms"
+const KillerDrainFailureAction = "killer.drain_failure.action"
+const KillerDrainFailureBackoffMs = "killer.drain_failure.backoff_ms"
 
 const ShifterEnabled = "shifter.enabled"
 const ShifterPollIntervalMs = "shifter.poll_interval_ms"
 const ShifterWhiteListIntervalHours = "shifter.white_list_interval_hours"
+const ShifterWhiteListTimezone = "shifter.white_list_timezone"
+const ShifterWhiteListDayIntervalHours = "shifter.white_list_day_interval_hours"
 const ShifterNPResizeTimeout = "shifter.np_resize_timeout_mins"
 const ShifterSleepAfterNodeDeletionMs = "shifter.sleep_after_node_deletion_ms"
 
+const BlackoutDates = "blackout.dates"
+const BlackoutFile = "blackout.file"
+const BlackoutConfigMap = "blackout.config_map"
+const BlackoutNamespace = "blackout.namespace"
+
+const PauseConfigMap = "pause.config_map"
+const PauseNamespace = "pause.namespace"
+
+const DisruptionMaxConcurrentDrains = "disruption.max_concurrent_drains"
+const DisruptionMaxConcurrentDrainsPerNodePool = "disruption.max_concurrent_drains_per_nodepool"
+
+const LeaderElectionEnabled = "leader_election.enabled"
+const LeaderElectionLeaseName = "leader_election.lease_name"
+const LeaderElectionNamespace = "leader_election.namespace"
+const LeaderElectionLeaseDurationMs = "leader_election.lease_duration_ms"
+const LeaderElectionRenewDeadlineMs = "leader_election.renew_deadline_ms"
+const LeaderElectionRetryPeriodMs = "leader_election.retry_period_ms"
+
+const GCloudOperationTimeoutMs = "gcloud.operation_timeout_ms"
+const GCloudRetries = "gcloud.retries"
+const GCloudBackoffMs = "gcloud.backoff_ms"
+const GCloudMIGAction = "gcloud.mig_action"
+
+const AdminToken = "admin.token"
+
 const ClientServerRetries = "client.server_retries"
 const ClientWatchMaintainanceEvents = "client.watch_maintainance_events"
 
@@ -39,14 +100,40 @@ const SlackTimeoutMs = "slack.slack_timeout"
 const EventGetNodes = "GET_NODES"
 const EventAnnotate = "ANNOTATE"
 const EventDrain = "DRAIN"
+const EventPDBBlocked = "PDB BLOCKED"
 const EventCordon = "CORDON"
+const EventUpdateNode = "UPDATE NODE"
+const EventCreatePod = "CREATE POD"
+const EventDeletePod = "DELETE POD"
+const EventEvictPod = "EVICT POD"
 const EventDeleteNode = "DELETE NODE"
 const EventDeleteInstance = "DELETE INSTANCE"
+const EventRecreateInstance = "RECREATE INSTANCE"
+const EventOrphanInstance = "ORPHAN INSTANCE"
 const EventShift = "SHIFT"
 const EventResizeNodePool = "RESIZE_NP"
+const EventLeaderElection = "LEADER ELECTION"
+const EventBlackout = "BLACKOUT"
+const EventSurge = "SURGE"
+const EventHealthGate = "HEALTH GATE"
+const EventRollback = "ROLLBACK"
+const EventEscalation = "ESCALATION"
+const EventPolicy = "POLICY"
+const EventConfig = "CONFIG"
+const EventAdmin = "ADMIN"
+const EventPause = "PAUSE"
 
 const CommaSeparater = ","
 
 const EvacuatePodsURI = "/evacuatepods"
+const EvacuationURI = "/evacuatepods/{id}"
+const NodesURI = "/api/v1/nodes"
+const ScheduleURI = "/api/v1/schedule"
+const ShifterURI = "/api/v1/shifter"
+const RescheduleNodeURI = "/api/v1/nodes/{name}/reschedule"
+const KillNodeURI = "/api/v1/nodes/{name}/kill"
+const ExcludeNodeURI = "/api/v1/nodes/{name}/exclude"
+const PauseURI = "/api/v1/pause"
+const ResumeURI = "/api/v1/resume"
 
 const NodePoolLabel = "prometheus_metrics.nodepool_label"
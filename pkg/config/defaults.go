@@ -0,0 +1,115 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+//EnvPrefix is the prefix of the environment variables overriding the configuration keys.
+//A key is overridden by the upper-cased variable with its dots replaced by underscores,
+//like SA_SPOTTER_POLL_INTERVAL_MS for spotter.poll_interval_ms.
+const EnvPrefix = "SA"
+
+//defaults holds the value of every configuration key which is not set by a flag, the environment or the file.
+var defaults = map[string]interface{}{
+	KubernetesRunMode: "InCluster",
+
+	ServerListenHost: "0.0.0.0",
+	ServerHost:       "http://silent-assassin.default.svc.cluster.local",
+	ServerPort:       8080,
+
+	DryRun:        false,
+	NodeSelectors: "cloud.google.com/gke-preemptible=true",
+
+	Policies:                  []interface{}{},
+	PolicyCRDEnabled:          false,
+	PolicyCRDStatusIntervalMs: 60000,
+
+	SpotterPollIntervalMs:             60000,
+	SpotterWhiteListIntervalHours:     "00:00-06:00",
+	SpotterWhiteListTimezone:          "",
+	SpotterWhiteListDayIntervalHours:  map[string]string{},
+	SpotterSlotMinutes:                10,
+	SpotterMaxKillsPerSlotPerNodePool: 0,
+	SpotterMaxKillsPerSlotPerZone:     0,
+
+	KillerPollIntervalMs:                     60000,
+	KillerDrainingTimeoutWhenNodeExpiredMs:   300000,
+	KillerDrainingTimeoutWhenNodePreemptedMs: 30000,
+	KillerEvictionRetries:                    5,
+	KillerEvictionBackoffMs:                  5000,
+	KillerSurgeMode:                          "",
+	KillerSurgeTimeoutMs:                     600000,
+	KillerSurgePlaceholderNamespace:          "silent-assassin",
+	KillerSurgePlaceholderImage:              "k8s.gcr.io/pause:3.2",
+	KillerHealthGateTimeoutMs:                300000,
+	KillerDrainFailureAction:                 "uncordon",
+	KillerDrainFailureBackoffMs:              1800000,
+
+	ShifterEnabled:                   false,
+	ShifterPollIntervalMs:            1200000,
+	ShifterWhiteListIntervalHours:    "12:00-21:30",
+	ShifterWhiteListTimezone:         "",
+	ShifterWhiteListDayIntervalHours: map[string]string{},
+	ShifterNPResizeTimeout:           10,
+	ShifterSleepAfterNodeDeletionMs:  120000,
+
+	BlackoutDates:     []string{},
+	BlackoutFile:      "",
+	BlackoutConfigMap: "",
+	BlackoutNamespace: "default",
+
+	PauseConfigMap: "silent-assassin-pause",
+	PauseNamespace: "default",
+
+	DisruptionMaxConcurrentDrains:            0,
+	DisruptionMaxConcurrentDrainsPerNodePool: 0,
+
+	LeaderElectionEnabled:         false,
+	LeaderElectionLeaseName:       "silent-assassin",
+	LeaderElectionNamespace:       "default",
+	LeaderElectionLeaseDurationMs: 15000,
+	LeaderElectionRenewDeadlineMs: 10000,
+	LeaderElectionRetryPeriodMs:   2000,
+
+	GCloudOperationTimeoutMs: 300000,
+	GCloudRetries:            5,
+	GCloudBackoffMs:          2000,
+	GCloudMIGAction:          "recreate",
+
+	AdminToken: "",
+
+	ClientServerRetries:           4,
+	ClientWatchMaintainanceEvents: true,
+
+	LogLevel: "info",
+
+	SlackWebhookURL: "",
+	SlackUsername:   "SILENT ASSASSIN",
+	SlackChannel:    "",
+	SlackIconURL:    "",
+	SlackTimeoutMs:  2000,
+
+	NodePoolLabel: NodePoolNameLabel,
+}
+
+//newViper returns a viper reading the configuration file, where a set flag takes precedence over the
+//environment, which takes precedence over the file, which takes precedence over the defaults.
+func newViper(cfgFile string, flags map[string]*pflag.Flag) (*viper.Viper, error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for key, flag := range flags {
+		if err := v.BindPFlag(key, flag); err != nil {
+			return nil, err
+		}
+	}
+	v.SetConfigFile(cfgFile)
+	return v, nil
+}
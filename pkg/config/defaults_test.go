@@ -0,0 +1,78 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DefaultsTestSuite struct {
+	suite.Suite
+	dir        string
+	configFile string
+}
+
+func (dt *DefaultsTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		dt.T().Fatal(err)
+	}
+	dt.dir = dir
+	dt.configFile = filepath.Join(dir, "application.yaml")
+	if err := ioutil.WriteFile(dt.configFile, []byte("LOGGER:\n  LEVEL: warn\nKILLER:\n  POLL_INTERVAL_MS: 1000\n"), 0644); err != nil {
+		dt.T().Fatal(err)
+	}
+}
+
+func (dt *DefaultsTestSuite) TearDownTest() {
+	os.RemoveAll(dt.dir)
+	os.Unsetenv("SA_LOGGER_LEVEL")
+	os.Unsetenv("SA_KILLER_EVICTION_RETRIES")
+}
+
+func (dt *DefaultsTestSuite) TestShouldValidateMinimalConfigWithDefaults() {
+	cp := Init(dt.configFile)
+
+	assert.Equal(dt.T(), 1000, cp.GetInt(KillerPollIntervalMs))
+	assert.Equal(dt.T(), 300000, cp.GetInt(KillerDrainingTimeoutWhenNodeExpiredMs))
+	assert.Equal(dt.T(), "InCluster", cp.GetString(KubernetesRunMode))
+
+	c, err := Read(cp)
+	assert.Nil(dt.T(), err)
+	assert.Nil(dt.T(), c.ValidateServer())
+	assert.Nil(dt.T(), c.ValidateClient())
+}
+
+func (dt *DefaultsTestSuite) TestShouldOverrideFileWithEnvAndFlags() {
+	os.Setenv("SA_LOGGER_LEVEL", "error")
+	os.Setenv("SA_KILLER_EVICTION_RETRIES", "7")
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("log-level", "", "")
+	flags.String("run-mode", "", "")
+
+	cp := Init(dt.configFile)
+	assert.Nil(dt.T(), cp.BindFlags(map[string]*pflag.Flag{
+		LogLevel:          flags.Lookup("log-level"),
+		KubernetesRunMode: flags.Lookup("run-mode"),
+	}))
+	assert.Equal(dt.T(), "error", cp.GetString(LogLevel))
+	assert.Equal(dt.T(), "InCluster", cp.GetString(KubernetesRunMode))
+
+	assert.Nil(dt.T(), flags.Parse([]string{"--log-level", "debug"}))
+	assert.Equal(dt.T(), "debug", cp.GetString(LogLevel))
+
+	var killer KillerConfig
+	assert.Nil(dt.T(), cp.UnmarshalKey("killer", &killer))
+	assert.Equal(dt.T(), 1000, killer.PollIntervalMs)
+	assert.Equal(dt.T(), 7, killer.EvictionRetries)
+	assert.Equal(dt.T(), 30000, killer.DrainingTimeoutWhenNodePreemptedMs)
+}
+
+func TestDefaultsTestSuite(t *testing.T) {
+	suite.Run(t, new(DefaultsTestSuite))
+}
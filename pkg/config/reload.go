@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+//OnChange registers a handler called after every reload which changed the configuration.
+func (f *Provider) OnChange(handler func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers = append(f.handlers, handler)
+}
+
+//Reload reads the configuration file again and, if it changed, validates it. A valid configuration replaces
+//the served one and the change handlers are called, an invalid one is rejected and the served one is kept.
+//Reload returns true if the configuration was replaced.
+func (f *Provider) Reload(validate func(IProvider) error) (bool, error) {
+	f.mu.RLock()
+	flags := f.flags
+	f.mu.RUnlock()
+	v, err := read(f.file, flags)
+	if err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(v.AllSettings(), f.get().AllSettings()) {
+		return false, nil
+	}
+
+	if validate != nil {
+		if err := validate(&Provider{file: f.file, flags: flags, viper: v}); err != nil {
+			return false, err
+		}
+	}
+
+	f.mu.Lock()
+	f.viper = v
+	handlers := append([]func(){}, f.handlers...)
+	f.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler()
+	}
+	return true, nil
+}
+
+//Watch reloads the configuration whenever the directory of the configuration file changes, until the context
+//is cancelled. The directory is watched rather than the file, as a mounted ConfigMap is updated by swapping
+//a symlink. The outcome of every reload is passed to report.
+func (f *Provider) Watch(ctx context.Context, validate func(IProvider) error, report func(changed bool, err error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(f.file)); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			report(f.Reload(validate))
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			report(false, err)
+		}
+	}
+}
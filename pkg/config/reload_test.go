@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReloadTestSuite struct {
+	suite.Suite
+	dir        string
+	configFile string
+}
+
+func (rt *ReloadTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		rt.T().Fatal(err)
+	}
+	rt.dir = dir
+	rt.configFile = filepath.Join(dir, "application.yaml")
+	rt.write("KILLER:\n  POLL_INTERVAL_MS: 1000\n")
+}
+
+func (rt *ReloadTestSuite) TearDownTest() {
+	os.RemoveAll(rt.dir)
+}
+
+func (rt *ReloadTestSuite) write(content string) {
+	if err := ioutil.WriteFile(rt.configFile, []byte(content), 0644); err != nil {
+		rt.T().Fatal(err)
+	}
+}
+
+func (rt *ReloadTestSuite) TestShouldReloadChangedConfig() {
+	cp := Init(rt.configFile)
+	changes := 0
+	cp.OnChange(func() { changes++ })
+
+	rt.write("KILLER:\n  POLL_INTERVAL_MS: 2000\n")
+	changed, err := cp.Reload(nil)
+	assert.True(rt.T(), changed)
+	assert.Nil(rt.T(), err)
+	assert.Equal(rt.T(), 2000, cp.GetInt(KillerPollIntervalMs))
+	assert.Equal(rt.T(), 1, changes)
+
+	changed, err = cp.Reload(nil)
+	assert.False(rt.T(), changed)
+	assert.Nil(rt.T(), err)
+	assert.Equal(rt.T(), 1, changes)
+}
+
+func (rt *ReloadTestSuite) TestShouldKeepLastValidConfig() {
+	cp := Init(rt.configFile)
+	changes := 0
+	cp.OnChange(func() { changes++ })
+
+	rt.write("KILLER: [\n")
+	changed, err := cp.Reload(nil)
+	assert.False(rt.T(), changed)
+	assert.NotNil(rt.T(), err)
+
+	rt.write("KILLER:\n  POLL_INTERVAL_MS: 0\n")
+	changed, err = cp.Reload(func(candidate IProvider) error {
+		if candidate.GetInt(KillerPollIntervalMs) == 0 {
+			return errors.New("poll interval cannot be 0")
+		}
+		return nil
+	})
+	assert.False(rt.T(), changed)
+	assert.EqualError(rt.T(), err, "poll interval cannot be 0")
+
+	assert.Equal(rt.T(), 1000, cp.GetInt(KillerPollIntervalMs))
+	assert.Equal(rt.T(), 0, changes)
+}
+
+func (rt *ReloadTestSuite) TestShouldReloadWhenTheFileChanges() {
+	cp := Init(rt.configFile)
+	reloaded := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watching := make(chan error, 1)
+	go func() {
+		watching <- cp.Watch(ctx, nil, func(changed bool, err error) {
+			if changed {
+				reloaded <- struct{}{}
+			}
+		})
+	}()
+
+	// The watch starts asynchronously, so the file is written until a reload is reported.
+	for i := 0; ; i++ {
+		rt.write("KILLER:\n  POLL_INTERVAL_MS: 3000\n")
+		select {
+		case <-reloaded:
+			assert.Equal(rt.T(), 3000, cp.GetInt(KillerPollIntervalMs))
+			cancel()
+			assert.Nil(rt.T(), <-watching)
+			return
+		case <-time.After(100 * time.Millisecond):
+			if i == 20 {
+				rt.T().Fatal("the change of the file was not reloaded")
+			}
+		}
+	}
+}
+
+func TestReloadTestSuite(t *testing.T) {
+	suite.Run(t, new(ReloadTestSuite))
+}
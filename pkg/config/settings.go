@@ -0,0 +1,319 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+//minShifterPollInterval is the shortest poll interval of the Shifter, a shift takes up to about 15 minutes.
+const minShifterPollInterval = 15 * time.Minute
+
+//Config is the typed content of the configuration file, used to validate it.
+//The services read the values through the IProvider, so they see the values of a reload.
+type Config struct {
+	ServerListenHost string
+	ServerHost       string
+	ServerPort       int
+	Spotter          SpotterConfig
+	Killer           KillerConfig
+	Shifter          ShifterConfig
+	PolicyCRD        PolicyCRDConfig
+	LeaderElection   LeaderElectionConfig
+	GCloud           GCloudConfig
+	Slack            SlackConfig
+	Client           ClientConfig
+}
+
+//WindowConfig is a whitelist of HH:MM-HH:MM intervals, comma separated, which can be replaced on named days.
+type WindowConfig struct {
+	WhiteListIntervalHours    string            `mapstructure:"white_list_interval_hours"`
+	WhiteListTimezone         string            `mapstructure:"white_list_timezone"`
+	WhiteListDayIntervalHours map[string]string `mapstructure:"white_list_day_interval_hours"`
+}
+
+type SpotterConfig struct {
+	WindowConfig   `mapstructure:",squash"`
+	PollIntervalMs int `mapstructure:"poll_interval_ms"`
+}
+
+type KillerConfig struct {
+	PollIntervalMs                     int                `mapstructure:"poll_interval_ms"`
+	DrainingTimeoutWhenNodeExpiredMs   int                `mapstructure:"draining_timeout_when_node_expired_ms"`
+	DrainingTimeoutWhenNodePreemptedMs int                `mapstructure:"draining_timeout_when_node_preempted_ms"`
+	EvictionRetries                    int                `mapstructure:"eviction_retries"`
+	EvictionBackoffMs                  int                `mapstructure:"eviction_backoff_ms"`
+	HealthGateTimeoutMs                int                `mapstructure:"health_gate_timeout_ms"`
+	DrainFailure                       DrainFailureConfig `mapstructure:"drain_failure"`
+	Surge                              SurgeConfig        `mapstructure:"surge"`
+}
+
+type DrainFailureConfig struct {
+	Action    string `mapstructure:"action"`
+	BackoffMs int    `mapstructure:"backoff_ms"`
+}
+
+type SurgeConfig struct {
+	Mode                 string `mapstructure:"mode"`
+	TimeoutMs            int    `mapstructure:"timeout_ms"`
+	PlaceholderNamespace string `mapstructure:"placeholder_namespace"`
+	PlaceholderImage     string `mapstructure:"placeholder_image"`
+}
+
+type ShifterConfig struct {
+	WindowConfig             `mapstructure:",squash"`
+	Enabled                  bool `mapstructure:"enabled"`
+	PollIntervalMs           int  `mapstructure:"poll_interval_ms"`
+	NPResizeTimeoutMins      int  `mapstructure:"np_resize_timeout_mins"`
+	SleepAfterNodeDeletionMs int  `mapstructure:"sleep_after_node_deletion_ms"`
+}
+
+type PolicyCRDConfig struct {
+	Enabled          bool `mapstructure:"enabled"`
+	StatusIntervalMs int  `mapstructure:"status_interval_ms"`
+}
+
+type LeaderElectionConfig struct {
+	Enabled         bool   `mapstructure:"enabled"`
+	LeaseName       string `mapstructure:"lease_name"`
+	Namespace       string `mapstructure:"namespace"`
+	LeaseDurationMs int    `mapstructure:"lease_duration_ms"`
+	RenewDeadlineMs int    `mapstructure:"renew_deadline_ms"`
+	RetryPeriodMs   int    `mapstructure:"retry_period_ms"`
+}
+
+type GCloudConfig struct {
+	OperationTimeoutMs int    `mapstructure:"operation_timeout_ms"`
+	Retries            int    `mapstructure:"retries"`
+	BackoffMs          int    `mapstructure:"backoff_ms"`
+	MIGAction          string `mapstructure:"mig_action"`
+}
+
+type SlackConfig struct {
+	WebhookURL string `mapstructure:"webhook_url"`
+	Username   string `mapstructure:"username"`
+	Channel    string `mapstructure:"channel"`
+	IconURL    string `mapstructure:"slack_icon_url"`
+}
+
+type ClientConfig struct {
+	ServerRetries           int  `mapstructure:"server_retries"`
+	WatchMaintainanceEvents bool `mapstructure:"watch_maintainance_events"`
+}
+
+//Errors lists every invalid value found in a configuration.
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+//Read decodes the values of the configuration into a Config.
+func Read(cp IProvider) (Config, error) {
+	c := Config{
+		ServerListenHost: cp.GetString(ServerListenHost),
+		ServerHost:       cp.GetString(ServerHost),
+		ServerPort:       cp.GetInt(ServerPort),
+	}
+	sections := []struct {
+		key   string
+		value interface{}
+	}{
+		{"spotter", &c.Spotter},
+		{"killer", &c.Killer},
+		{"shifter", &c.Shifter},
+		{"policy_crd", &c.PolicyCRD},
+		{"leader_election", &c.LeaderElection},
+		{"gcloud", &c.GCloud},
+		{"slack", &c.Slack},
+		{"client", &c.Client},
+	}
+	for _, section := range sections {
+		if err := cp.UnmarshalKey(section.key, section.value); err != nil {
+			return c, fmt.Errorf("invalid %s: %v", strings.ToUpper(section.key), err)
+		}
+	}
+	return c, nil
+}
+
+//ValidateServer returns the Errors of the values read by the server, nil if there are none.
+func (c Config) ValidateServer() error {
+	var errs Errors
+	errs = append(errs, positive(ServerPort, c.ServerPort)...)
+	errs = append(errs, c.Spotter.validate("SPOTTER")...)
+	errs = append(errs, positive(SpotterPollIntervalMs, c.Spotter.PollIntervalMs)...)
+
+	errs = append(errs, positive(KillerPollIntervalMs, c.Killer.PollIntervalMs)...)
+	errs = append(errs, positive(KillerDrainingTimeoutWhenNodeExpiredMs, c.Killer.DrainingTimeoutWhenNodeExpiredMs)...)
+	errs = append(errs, positive(KillerDrainingTimeoutWhenNodePreemptedMs, c.Killer.DrainingTimeoutWhenNodePreemptedMs)...)
+	errs = append(errs, notNegative(KillerEvictionRetries, c.Killer.EvictionRetries)...)
+	errs = append(errs, notNegative(KillerEvictionBackoffMs, c.Killer.EvictionBackoffMs)...)
+	errs = append(errs, notNegative(KillerHealthGateTimeoutMs, c.Killer.HealthGateTimeoutMs)...)
+	errs = append(errs, oneOf(KillerDrainFailureAction, c.Killer.DrainFailure.Action, "uncordon", "retry")...)
+	errs = append(errs, notNegative(KillerDrainFailureBackoffMs, c.Killer.DrainFailure.BackoffMs)...)
+	errs = append(errs, oneOf(KillerSurgeMode, c.Killer.Surge.Mode, "placeholder")...)
+	if c.Killer.Surge.Mode != "" {
+		errs = append(errs, positive(KillerSurgeTimeoutMs, c.Killer.Surge.TimeoutMs)...)
+	}
+
+	if c.Shifter.Enabled {
+		errs = append(errs, c.Shifter.validate("SHIFTER")...)
+		if strings.TrimSpace(c.Shifter.WhiteListIntervalHours) == "" {
+			errs = append(errs, fmt.Errorf("%s is empty", name(ShifterWhiteListIntervalHours)))
+		}
+		if time.Duration(c.Shifter.PollIntervalMs)*time.Millisecond <= minShifterPollInterval {
+			errs = append(errs, fmt.Errorf("%s is %d, it has to be greater than %v", name(ShifterPollIntervalMs), c.Shifter.PollIntervalMs, minShifterPollInterval))
+		}
+		errs = append(errs, positive(ShifterNPResizeTimeout, c.Shifter.NPResizeTimeoutMins)...)
+		errs = append(errs, notNegative(ShifterSleepAfterNodeDeletionMs, c.Shifter.SleepAfterNodeDeletionMs)...)
+	}
+
+	if c.PolicyCRD.Enabled {
+		errs = append(errs, positive(PolicyCRDStatusIntervalMs, c.PolicyCRD.StatusIntervalMs)...)
+	}
+
+	if c.LeaderElection.Enabled {
+		errs = append(errs, positive(LeaderElectionLeaseDurationMs, c.LeaderElection.LeaseDurationMs)...)
+		errs = append(errs, positive(LeaderElectionRenewDeadlineMs, c.LeaderElection.RenewDeadlineMs)...)
+		errs = append(errs, positive(LeaderElectionRetryPeriodMs, c.LeaderElection.RetryPeriodMs)...)
+		if c.LeaderElection.RenewDeadlineMs >= c.LeaderElection.LeaseDurationMs {
+			errs = append(errs, fmt.Errorf("%s has to be less than %s", name(LeaderElectionRenewDeadlineMs), name(LeaderElectionLeaseDurationMs)))
+		}
+	}
+
+	errs = append(errs, positive(GCloudOperationTimeoutMs, c.GCloud.OperationTimeoutMs)...)
+	errs = append(errs, notNegative(GCloudRetries, c.GCloud.Retries)...)
+	errs = append(errs, notNegative(GCloudBackoffMs, c.GCloud.BackoffMs)...)
+	errs = append(errs, oneOf(GCloudMIGAction, c.GCloud.MIGAction, "delete", "recreate")...)
+
+	errs = append(errs, c.Slack.validate()...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+//ValidateClient returns the Errors of the values read by the client, nil if there are none.
+func (c Config) ValidateClient() error {
+	var errs Errors
+	if u, err := url.ParseRequestURI(c.ServerHost); err != nil || u.Host == "" {
+		errs = append(errs, fmt.Errorf("%s %q is not a valid URL", name(ServerHost), c.ServerHost))
+	}
+	errs = append(errs, positive(ServerPort, c.ServerPort)...)
+	errs = append(errs, notNegative(ClientServerRetries, c.Client.ServerRetries)...)
+	errs = append(errs, c.Slack.validate()...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+//validate checks the timezone and the intervals of the whitelist, and the names of its days.
+func (w WindowConfig) validate(section string) Errors {
+	var errs Errors
+	if _, err := time.LoadLocation(w.WhiteListTimezone); err != nil {
+		errs = append(errs, fmt.Errorf("%s.WHITE_LIST_TIMEZONE %q is not a valid timezone", section, w.WhiteListTimezone))
+	}
+	if err := ValidateIntervals(strings.Split(w.WhiteListIntervalHours, CommaSeparater)); err != nil {
+		errs = append(errs, fmt.Errorf("%s.WHITE_LIST_INTERVAL_HOURS: %v", section, err))
+	}
+	days := make([]string, 0, len(w.WhiteListDayIntervalHours))
+	for day := range w.WhiteListDayIntervalHours {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	for _, day := range days {
+		intervals := w.WhiteListDayIntervalHours[day]
+		if !isWeekday(day) {
+			errs = append(errs, fmt.Errorf("%s.WHITE_LIST_DAY_INTERVAL_HOURS: unknown day %s", section, day))
+		}
+		if err := ValidateIntervals(strings.Split(intervals, CommaSeparater)); err != nil {
+			errs = append(errs, fmt.Errorf("%s.WHITE_LIST_DAY_INTERVAL_HOURS.%s: %v", section, strings.ToUpper(day), err))
+		}
+	}
+	return errs
+}
+
+//validate checks the Slack settings when a webhook is configured, Slack is not used without one.
+func (s SlackConfig) validate() Errors {
+	if s.WebhookURL == "" {
+		return nil
+	}
+	var errs Errors
+	if u, err := url.ParseRequestURI(s.WebhookURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		errs = append(errs, fmt.Errorf("%s is not a valid http(s) URL", name(SlackWebhookURL)))
+	}
+	if s.Username == "" {
+		errs = append(errs, fmt.Errorf("%s is empty", name(SlackUsername)))
+	}
+	if s.Channel == "" {
+		errs = append(errs, fmt.Errorf("%s is empty", name(SlackChannel)))
+	}
+	return errs
+}
+
+//ValidateIntervals checks that the whitelist intervals are in HH:MM-HH:MM format. Blank intervals are ignored.
+func ValidateIntervals(intervals []string) error {
+	for _, interval := range intervals {
+		if strings.TrimSpace(interval) == "" {
+			continue
+		}
+		times := strings.Split(strings.TrimSpace(interval), "-")
+		if len(times) != 2 {
+			return fmt.Errorf("invalid interval %s, expected HH:MM-HH:MM", interval)
+		}
+		for _, t := range times {
+			if _, err := time.Parse("15:04", t); err != nil {
+				return fmt.Errorf("invalid interval %s, expected HH:MM-HH:MM", interval)
+			}
+		}
+	}
+	return nil
+}
+
+func isWeekday(name string) bool {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+//name returns the key as it is written in the configuration file.
+func name(key string) string {
+	return strings.ToUpper(key)
+}
+
+func positive(key string, value int) Errors {
+	if value <= 0 {
+		return Errors{fmt.Errorf("%s is %d, it has to be greater than 0", name(key), value)}
+	}
+	return nil
+}
+
+func notNegative(key string, value int) Errors {
+	if value < 0 {
+		return Errors{fmt.Errorf("%s is %d, it cannot be negative", name(key), value)}
+	}
+	return nil
+}
+
+//oneOf checks that the value is empty, for the default, or one of the allowed values.
+func oneOf(key, value string, allowed ...string) Errors {
+	if value == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return Errors{fmt.Errorf("%s is %q, expected one of %s", name(key), value, strings.Join(allowed, ", "))}
+}
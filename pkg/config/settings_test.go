@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const validConfig = `
+SERVER_HOST: http://silent-assassin.default.svc.cluster.local
+SERVER_PORT: 8080
+SPOTTER:
+  POLL_INTERVAL_MS: 60000
+  WHITE_LIST_INTERVAL_HOURS: 01:00-06:00
+  WHITE_LIST_TIMEZONE: Asia/Kolkata
+  WHITE_LIST_DAY_INTERVAL_HOURS:
+    SUNDAY: 00:00-10:00
+KILLER:
+  POLL_INTERVAL_MS: 60000
+  DRAINING_TIMEOUT_WHEN_NODE_EXPIRED_MS: 300000
+  DRAINING_TIMEOUT_WHEN_NODE_PREEMPTED_MS: 30000
+  DRAIN_FAILURE:
+    ACTION: uncordon
+SHIFTER:
+  ENABLED: true
+  POLL_INTERVAL_MS: 1200000
+  WHITE_LIST_INTERVAL_HOURS: 12:00-21:30
+  NP_RESIZE_TIMEOUT_MINS: 10
+GCLOUD:
+  OPERATION_TIMEOUT_MS: 300000
+SLACK:
+  WEBHOOK_URL: https://hooks.slack.com/services/T/B/X
+  USERNAME: SILENT ASSASSIN
+  CHANNEL: silent-assassin-alerts
+CLIENT:
+  SERVER_RETRIES: 4
+`
+
+type SettingsTestSuite struct {
+	suite.Suite
+	dir string
+}
+
+func (st *SettingsTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		st.T().Fatal(err)
+	}
+	st.dir = dir
+}
+
+func (st *SettingsTestSuite) TearDownTest() {
+	os.RemoveAll(st.dir)
+}
+
+func (st *SettingsTestSuite) read(content string) Config {
+	configFile := filepath.Join(st.dir, "application.yaml")
+	if err := ioutil.WriteFile(configFile, []byte(content), 0644); err != nil {
+		st.T().Fatal(err)
+	}
+	cp, err := Load(configFile)
+	assert.Nil(st.T(), err)
+	c, err := Read(cp)
+	assert.Nil(st.T(), err)
+	return c
+}
+
+func (st *SettingsTestSuite) TestShouldAcceptValidConfig() {
+	c := st.read(validConfig)
+
+	assert.Equal(st.T(), "01:00-06:00", c.Spotter.WhiteListIntervalHours)
+	assert.Equal(st.T(), map[string]string{"sunday": "00:00-10:00"}, c.Spotter.WhiteListDayIntervalHours)
+	assert.True(st.T(), c.Shifter.Enabled)
+	assert.Nil(st.T(), c.ValidateServer())
+	assert.Nil(st.T(), c.ValidateClient())
+}
+
+func (st *SettingsTestSuite) TestShouldListEveryInvalidValue() {
+	c := st.read(validConfig)
+	c.Spotter.WhiteListIntervalHours = "1-6"
+	c.Spotter.WhiteListTimezone = "Mars/Olympus"
+	c.Spotter.WhiteListDayIntervalHours = map[string]string{"funday": "00:00-10:00"}
+	c.Killer.DrainingTimeoutWhenNodeExpiredMs = 0
+	c.Killer.DrainFailure.Action = "ignore"
+	c.Shifter.PollIntervalMs = 600000
+	c.Slack.WebhookURL = "<slack-url>"
+	c.Slack.Channel = ""
+
+	err := c.ValidateServer()
+	assert.Equal(st.T(), Errors{
+		errors.New("SPOTTER.WHITE_LIST_TIMEZONE \"Mars/Olympus\" is not a valid timezone"),
+		errors.New("SPOTTER.WHITE_LIST_INTERVAL_HOURS: invalid interval 1-6, expected HH:MM-HH:MM"),
+		errors.New("SPOTTER.WHITE_LIST_DAY_INTERVAL_HOURS: unknown day funday"),
+		errors.New("KILLER.DRAINING_TIMEOUT_WHEN_NODE_EXPIRED_MS is 0, it has to be greater than 0"),
+		errors.New("KILLER.DRAIN_FAILURE.ACTION is \"ignore\", expected one of uncordon, retry"),
+		errors.New("SHIFTER.POLL_INTERVAL_MS is 600000, it has to be greater than 15m0s"),
+		errors.New("SLACK.WEBHOOK_URL is not a valid http(s) URL"),
+		errors.New("SLACK.CHANNEL is empty"),
+	}.Error(), err.Error())
+}
+
+func (st *SettingsTestSuite) TestShouldNotValidateDisabledShifter() {
+	c := st.read(validConfig)
+	c.Shifter.Enabled = false
+	c.Shifter.PollIntervalMs = 0
+	c.Shifter.WhiteListIntervalHours = ""
+
+	assert.Nil(st.T(), c.ValidateServer())
+}
+
+func (st *SettingsTestSuite) TestShouldValidateIntervals() {
+	assert.Nil(st.T(), ValidateIntervals([]string{"01:00-06:00", " 22:00-23:30", ""}))
+	assert.EqualError(st.T(), ValidateIntervals([]string{"01:00-06:00", "3-4"}), "invalid interval 3-4, expected HH:MM-HH:MM")
+	assert.NotNil(st.T(), ValidateIntervals([]string{"01:00"}))
+}
+
+func TestSettingsTestSuite(t *testing.T) {
+	suite.Run(t, new(SettingsTestSuite))
+}
@@ -0,0 +1,152 @@
+package disruption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	v1 "k8s.io/api/core/v1"
+)
+
+//ErrNoDrainSlot is returned when no drain slot got free before the deadline of the drain.
+var ErrNoDrainSlot = errors.New("no drain slot got free before the drain timeout")
+
+//ICoordinator hands out the permission to drain a node. Every drain, on expiry, shift or preemption, acquires it first.
+type ICoordinator interface {
+	Acquire(ctx context.Context, node v1.Node, preemption bool) (release func(), err error)
+}
+
+//Coordinator limits the nodes drained at once, in total and per nodepool. Nodes wait in a queue
+//for a free slot, in arrival order. Preemptions do not wait, as the node goes away in 30 seconds,
+//but they take a slot, so the other nodes wait for them. The limits are read from the configuration
+//on every acquire and release, 0 means no limit. The nodepool of a node is read from the
+//prometheus_metrics.nodepool_label label, like the policies.
+type Coordinator struct {
+	cp                  config.IProvider
+	logger              logger.IZapLogger
+	mutex               sync.Mutex
+	draining            int
+	drainingPerNodePool map[string]int
+	queue               []*waiter
+}
+
+type waiter struct {
+	node     string
+	nodePool string
+	granted  chan struct{}
+}
+
+func NewCoordinator(cp config.IProvider, zl logger.IZapLogger) *Coordinator {
+	return &Coordinator{
+		cp:                  cp,
+		logger:              zl,
+		drainingPerNodePool: make(map[string]int),
+	}
+}
+
+//Acquire blocks until the node can be drained and returns the function releasing its slot once the drain is over.
+//The wait is part of the drain, so Acquire gives up with ErrNoDrainSlot once the context of the drain is done.
+func (c *Coordinator) Acquire(ctx context.Context, node v1.Node, preemption bool) (func(), error) {
+	w := &waiter{
+		node:     node.Name,
+		nodePool: node.Labels[c.nodePoolLabel()],
+		granted:  make(chan struct{}),
+	}
+
+	c.mutex.Lock()
+	if preemption {
+		c.grant(w)
+	} else {
+		c.queue = append(c.queue, w)
+		c.dispatch()
+	}
+	c.mutex.Unlock()
+
+	select {
+	case <-w.granted:
+	default:
+		c.logger.Info(fmt.Sprintf("Disruption: node %s is waiting for a drain slot", w.node))
+		select {
+		case <-w.granted:
+		case <-ctx.Done():
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			if !c.remove(w) {
+				// The slot was granted while the context got done.
+				c.release(w)
+			}
+			c.logger.Info(fmt.Sprintf("Disruption: node %s gave up waiting for a drain slot", w.node))
+			return func() {}, ErrNoDrainSlot
+		}
+	}
+
+	c.logger.Debug(fmt.Sprintf("Disruption: node %s acquired a drain slot, preemption: %t", w.node, preemption))
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			c.release(w)
+			c.logger.Debug(fmt.Sprintf("Disruption: node %s released its drain slot", w.node))
+		})
+	}, nil
+}
+
+//grant gives a slot to the waiter. It has to be called with the mutex held.
+func (c *Coordinator) grant(w *waiter) {
+	c.draining++
+	c.drainingPerNodePool[w.nodePool]++
+	close(w.granted)
+}
+
+//release frees the slot of the waiter and hands it to the next waiters. It has to be called with the mutex held.
+func (c *Coordinator) release(w *waiter) {
+	c.draining--
+	c.drainingPerNodePool[w.nodePool]--
+	c.dispatch()
+}
+
+//remove removes the waiter from the queue and returns false if it is not queued anymore.
+//It has to be called with the mutex held.
+func (c *Coordinator) remove(w *waiter) bool {
+	for i, queued := range c.queue {
+		if queued == w {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+//dispatch grants the slots to the waiters in queue order. A waiter whose nodepool is at its limit
+//does not hold back the waiters of other nodepools. It has to be called with the mutex held.
+func (c *Coordinator) dispatch() {
+	maxDrains := c.cp.GetInt(config.DisruptionMaxConcurrentDrains)
+	maxDrainsPerNodePool := c.cp.GetInt(config.DisruptionMaxConcurrentDrainsPerNodePool)
+
+	remaining := []*waiter{}
+	for _, w := range c.queue {
+		if maxDrains > 0 && c.draining >= maxDrains {
+			remaining = append(remaining, w)
+			continue
+		}
+		if maxDrainsPerNodePool > 0 && c.drainingPerNodePool[w.nodePool] >= maxDrainsPerNodePool {
+			remaining = append(remaining, w)
+			continue
+		}
+		c.grant(w)
+	}
+	c.queue = remaining
+}
+
+//nodePoolLabel returns the label holding the nodepool of the nodes, the GKE nodepool label when it is not set.
+func (c *Coordinator) nodePoolLabel() string {
+	if label := c.cp.GetString(config.NodePoolLabel); label != "" {
+		return label
+	}
+	return config.NodePoolNameLabel
+}
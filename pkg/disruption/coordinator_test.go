@@ -0,0 +1,150 @@
+package disruption
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type CoordinatorTestSuite struct {
+	suite.Suite
+	configMock *config.ProviderMock
+	logger     logger.IZapLogger
+}
+
+func (ct *CoordinatorTestSuite) SetupTest() {
+	ct.configMock = new(config.ProviderMock)
+	ct.configMock.On("GetString", config.NodePoolLabel).Return(config.NodePoolNameLabel)
+	ct.configMock.On("GetString", mock.Anything).Return("debug")
+	ct.logger = logger.Init(ct.configMock)
+}
+
+func node(name, nodePool string) v1.Node {
+	return v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{config.NodePoolNameLabel: nodePool}}}
+}
+
+//acquire acquires the node in the background and sends its name and release function once granted.
+func acquire(c *Coordinator, node v1.Node, preemption bool, granted chan<- string, releases map[string]func(), done chan<- struct{}) {
+	go func() {
+		release, _ := c.Acquire(context.Background(), node, preemption)
+		releases[node.Name] = release
+		granted <- node.Name
+		done <- struct{}{}
+	}()
+}
+
+func (ct *CoordinatorTestSuite) waitForQueue(c *Coordinator, length int) {
+	for i := 0; i < 100; i++ {
+		c.mutex.Lock()
+		queued := len(c.queue)
+		c.mutex.Unlock()
+		if queued == length {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	ct.T().Fatalf("queue did not reach %d waiters", length)
+}
+
+func (ct *CoordinatorTestSuite) TestShouldLimitDrainsAndLetPreemptionsBypassTheLimit() {
+	ct.configMock.On("GetInt", config.DisruptionMaxConcurrentDrains).Return(1)
+	ct.configMock.On("GetInt", config.DisruptionMaxConcurrentDrainsPerNodePool).Return(0)
+	c := NewCoordinator(ct.configMock, ct.logger)
+
+	release, err := c.Acquire(context.Background(), node("node-1", "services-p-1"), false)
+	assert.Nil(ct.T(), err)
+
+	granted := make(chan string, 3)
+	releases := make(map[string]func())
+	done := make(chan struct{})
+	acquire(c, node("node-2", "services-p-1"), false, granted, releases, done)
+	ct.waitForQueue(c, 1)
+	acquire(c, node("node-3", "services-p-1"), true, granted, releases, done)
+
+	assert.Equal(ct.T(), "node-3", <-granted, "The preemption should not wait")
+	<-done
+	assert.Equal(ct.T(), 0, len(granted), "No node should be drained while node-1 and node-3 are draining")
+
+	// The preemption holds a slot, so node-2 waits for both drains.
+	release()
+	assert.Equal(ct.T(), 0, len(granted))
+	releases["node-3"]()
+	assert.Equal(ct.T(), "node-2", <-granted)
+	<-done
+	releases["node-2"]()
+}
+
+func (ct *CoordinatorTestSuite) TestShouldGiveUpWaitingOnceTheContextIsDone() {
+	ct.configMock.On("GetInt", config.DisruptionMaxConcurrentDrains).Return(1)
+	ct.configMock.On("GetInt", config.DisruptionMaxConcurrentDrainsPerNodePool).Return(0)
+	c := NewCoordinator(ct.configMock, ct.logger)
+
+	release, _ := c.Acquire(context.Background(), node("node-1", "services-p-1"), false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Acquire(ctx, node("node-2", "services-p-1"), false)
+	assert.Equal(ct.T(), ErrNoDrainSlot, err)
+	assert.Equal(ct.T(), 0, len(c.queue), "The node giving up should leave the queue")
+
+	release()
+	assert.Equal(ct.T(), 0, c.draining)
+}
+
+func (ct *CoordinatorTestSuite) TestShouldLimitDrainsPerNodePool() {
+	ct.configMock.On("GetInt", config.DisruptionMaxConcurrentDrains).Return(0)
+	ct.configMock.On("GetInt", config.DisruptionMaxConcurrentDrainsPerNodePool).Return(1)
+	c := NewCoordinator(ct.configMock, ct.logger)
+
+	release, _ := c.Acquire(context.Background(), node("node-1", "services-p-1"), false)
+
+	granted := make(chan string, 2)
+	releases := make(map[string]func())
+	done := make(chan struct{})
+	acquire(c, node("node-2", "services-p-1"), false, granted, releases, done)
+	ct.waitForQueue(c, 1)
+	acquire(c, node("node-3", "ingress-p-1"), false, granted, releases, done)
+
+	assert.Equal(ct.T(), "node-3", <-granted, "A node of another nodepool should not wait")
+	<-done
+
+	release()
+	release()
+	assert.Equal(ct.T(), "node-2", <-granted)
+	<-done
+	assert.Equal(ct.T(), 1, c.drainingPerNodePool["services-p-1"], "Releasing twice should free a single slot")
+}
+
+func (ct *CoordinatorTestSuite) TestShouldLimitDrainsPerNodePoolOfTheConfiguredLabel() {
+	ct.configMock.ExpectedCalls = nil
+	ct.configMock.On("GetString", config.NodePoolLabel).Return("example.com/pool")
+	ct.configMock.On("GetString", mock.Anything).Return("debug")
+	ct.configMock.On("GetInt", config.DisruptionMaxConcurrentDrains).Return(0)
+	ct.configMock.On("GetInt", config.DisruptionMaxConcurrentDrainsPerNodePool).Return(1)
+	c := NewCoordinator(ct.configMock, ct.logger)
+
+	// Both nodes are in the same GKE nodepool, but the configured label tells their nodepools apart.
+	services, batch := node("node-1", "services-p-1"), node("node-2", "services-p-1")
+	services.Labels["example.com/pool"] = "services"
+	batch.Labels["example.com/pool"] = "batch"
+
+	_, err := c.Acquire(context.Background(), services, false)
+	assert.Nil(ct.T(), err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = c.Acquire(ctx, batch, false)
+	assert.Nil(ct.T(), err, "A node of another nodepool should not wait")
+	assert.Equal(ct.T(), 1, c.drainingPerNodePool["batch"])
+}
+
+func TestCoordinatorTestSuite(t *testing.T) {
+	suite.Run(t, new(CoordinatorTestSuite))
+}
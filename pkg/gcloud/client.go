@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/roppenlabs/silent-assassin/pkg/config"
 	"github.com/roppenlabs/silent-assassin/pkg/k8s"
 	"golang.org/x/oauth2/google"
 	compute "google.golang.org/api/compute/v1"
@@ -12,6 +13,7 @@ import (
 )
 
 type GCloudClient struct {
+	cp                         config.IProvider
 	project                    string
 	location                   string
 	cluster                    string
@@ -23,6 +25,7 @@ type GCloudClient struct {
 
 type IGCloudClient interface {
 	DeleteInstance(zone, name string) error
+	RecreateInstance(zone, name string) error
 	GetInstance(project, zone, name string) (*compute.Instance, error)
 	ListNodePools() ([]*container.NodePool, error)
 	GetNodePool(npName string) (*container.NodePool, error)
@@ -30,7 +33,7 @@ type IGCloudClient interface {
 	GetNumberOfZones() int
 }
 
-func NewClient(kc k8s.IKubernetesClient) IGCloudClient {
+func NewClient(cp config.IProvider, kc k8s.IKubernetesClient) IGCloudClient {
 	var gClient GCloudClient
 
 	computeClientComputeScope, err := google.DefaultClient(context.Background(), compute.ComputeScope)
@@ -67,6 +70,7 @@ func NewClient(kc k8s.IKubernetesClient) IGCloudClient {
 		panic(err.Error())
 	}
 	gClient = GCloudClient{
+		cp:                         cp,
 		computeServiceComputeScope: computeServiceComputeScope,
 		containerServiceCloudScope: containerServiceCloudScope,
 		computeServiceCloudScope:   computeServiceCloudScope,
@@ -83,11 +87,6 @@ func NewClient(kc k8s.IKubernetesClient) IGCloudClient {
 	return gClient
 }
 
-func (client GCloudClient) DeleteInstance(zone, name string) error {
-	_, err := client.computeServiceComputeScope.Instances.Delete(client.project, zone, name).Context(context.Background()).Do()
-	return err
-}
-
 func (client GCloudClient) GetInstance(project, zone, name string) (*compute.Instance, error) {
 	instance, err := client.computeServiceComputeScope.Instances.Get(project, zone, name).Context(context.Background()).Do()
 	if err != nil {
@@ -17,6 +17,11 @@ func (m *GCloudClientMock) DeleteInstance(zone, name string) error {
 	return args.Error(0)
 }
 
+func (m *GCloudClientMock) RecreateInstance(zone, name string) error {
+	args := m.Called(zone, name)
+	return args.Error(0)
+}
+
 func (m *GCloudClientMock) GetInstance(project, zone, name string) (*compute.Instance, error) {
 	args := m.Called(project, zone, name)
 	return args.Get(0).(*compute.Instance), args.Error(1)
@@ -0,0 +1,46 @@
+package gcloud
+
+import (
+	"fmt"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+)
+
+//DryRunClient logs and notifies the instance deletions and node-pool resizes
+//instead of sending them to GCP.
+type DryRunClient struct {
+	IGCloudClient
+	logger   logger.IZapLogger
+	notifier notifier.INotifierClient
+}
+
+func NewDryRunClient(gc IGCloudClient, zl logger.IZapLogger, nf notifier.INotifierClient) DryRunClient {
+	zl.Info("Dry run: GCloud mutations will only be logged and notified")
+	return DryRunClient{
+		IGCloudClient: gc,
+		logger:        zl,
+		notifier:      nf,
+	}
+}
+
+func (dc DryRunClient) report(event, details string) {
+	dc.logger.Info(fmt.Sprintf("%s %s: %s", config.DryRunMarker, event, details))
+	dc.notifier.Info(fmt.Sprintf("%s %s", config.DryRunMarker, event), details)
+}
+
+func (dc DryRunClient) DeleteInstance(zone, name string) error {
+	dc.report(config.EventDeleteInstance, fmt.Sprintf("Instance: %s\nZone: %s", name, zone))
+	return nil
+}
+
+func (dc DryRunClient) RecreateInstance(zone, name string) error {
+	dc.report(config.EventRecreateInstance, fmt.Sprintf("Instance: %s\nZone: %s", name, zone))
+	return nil
+}
+
+func (dc DryRunClient) SetNodePoolSize(npName string, size int64, timeout int) error {
+	dc.report(config.EventResizeNodePool, fmt.Sprintf("Nodepool: %s\nSize: %d", npName, size))
+	return nil
+}
@@ -0,0 +1,114 @@
+package gcloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	compute "google.golang.org/api/compute/v1"
+)
+
+//createdByMetadataKey is the instance metadata key holding the URL of the managed instance group which created it.
+const createdByMetadataKey = "created-by"
+
+//getInstanceGroupManager returns the name of the managed instance group which created the instance,
+//an empty name if the instance is not managed by one.
+func getInstanceGroupManager(instance *compute.Instance) string {
+	if instance.Metadata == nil {
+		return ""
+	}
+	for _, metadata := range instance.Metadata.Items {
+		if metadata.Key != createdByMetadataKey || metadata.Value == nil {
+			continue
+		}
+		// The value is projects/<project number>/zones/<zone>/instanceGroupManagers/<name>
+		s := strings.Split(*metadata.Value, "/")
+		if len(s) < 2 || s[len(s)-2] != "instanceGroupManagers" {
+			return ""
+		}
+		return s[len(s)-1]
+	}
+	return ""
+}
+
+//DeleteInstance deletes the instance through the managed instance group owning it, which shrinks the group
+//so the instance is not recreated. An instance which is not managed by a group is deleted directly.
+//Transient errors are retried with exponential backoff and the operation is waited for, within the operation timeout.
+//An instance which does not exist anymore is not an error.
+func (client GCloudClient) DeleteInstance(zone, name string) error {
+	instance, err := client.getInstanceWithRetry(zone, name)
+	if isNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var op *compute.Operation
+	manager := getInstanceGroupManager(instance)
+	err = client.retry(func() error {
+		var err error
+		if manager == "" {
+			op, err = client.computeServiceComputeScope.Instances.Delete(client.project, zone, name).Context(context.Background()).Do()
+			return err
+		}
+		request := &compute.InstanceGroupManagersDeleteInstancesRequest{Instances: []string{instance.SelfLink}}
+		op, err = client.computeServiceComputeScope.InstanceGroupManagers.DeleteInstances(client.project, zone, manager, request).Context(context.Background()).Do()
+		return err
+	})
+	if isNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return client.waitForOperation(zone, op)
+}
+
+//RecreateInstance replaces the instance with a new one through the managed instance group owning it,
+//so the size of the group stays the same. It fails for an instance which is not managed by a group.
+func (client GCloudClient) RecreateInstance(zone, name string) error {
+	instance, err := client.getInstanceWithRetry(zone, name)
+	if err != nil {
+		return err
+	}
+
+	manager := getInstanceGroupManager(instance)
+	if manager == "" {
+		return fmt.Errorf("instance %s is not managed by an instance group", name)
+	}
+
+	var op *compute.Operation
+	err = client.retry(func() error {
+		var err error
+		request := &compute.InstanceGroupManagersRecreateInstancesRequest{Instances: []string{instance.SelfLink}}
+		op, err = client.computeServiceComputeScope.InstanceGroupManagers.RecreateInstances(client.project, zone, manager, request).Context(context.Background()).Do()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return client.waitForOperation(zone, op)
+}
+
+func (client GCloudClient) getInstanceWithRetry(zone, name string) (*compute.Instance, error) {
+	var instance *compute.Instance
+	err := client.retry(func() error {
+		var err error
+		instance, err = client.GetInstance(client.project, zone, name)
+		return err
+	})
+	return instance, err
+}
+
+//waitForOperation waits for the zonal operation to complete within the operation timeout.
+func (client GCloudClient) waitForOperation(zone string, op *compute.Operation) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*time.Duration(client.cp.GetInt(config.GCloudOperationTimeoutMs)))
+	defer cancel()
+
+	return client.waitForZoneOperation(ctx, zone, op.Name)
+}
@@ -0,0 +1,37 @@
+package gcloud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	compute "google.golang.org/api/compute/v1"
+)
+
+type MIGTestSuite struct {
+	suite.Suite
+}
+
+func instanceCreatedBy(value string) *compute.Instance {
+	return &compute.Instance{
+		Metadata: &compute.Metadata{
+			Items: []*compute.MetadataItems{
+				{Key: "cluster-name", Value: &value},
+				{Key: "created-by", Value: &value},
+			}}}
+}
+
+func (m *MIGTestSuite) TestShouldGetInstanceGroupManagerFromCreatedBy() {
+	instance := instanceCreatedBy("projects/123456/zones/asia-south1-a/instanceGroupManagers/gke-cluster-services-p-1-abcd-grp")
+
+	assert.Equal(m.T(), "gke-cluster-services-p-1-abcd-grp", getInstanceGroupManager(instance))
+}
+
+func (m *MIGTestSuite) TestShouldNotGetInstanceGroupManagerOfUnmanagedInstance() {
+	assert.Equal(m.T(), "", getInstanceGroupManager(&compute.Instance{}))
+	assert.Equal(m.T(), "", getInstanceGroupManager(instanceCreatedBy("projects/123456/zones/asia-south1-a/instances/other")))
+}
+
+func TestMIGTestSuite(t *testing.T) {
+	suite.Run(t, new(MIGTestSuite))
+}
@@ -0,0 +1,86 @@
+package gcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+//zoneOperationPollInterval is the interval at which a zonal operation is checked for completion.
+const zoneOperationPollInterval = 5 * time.Second
+
+//isTransient returns true for the errors worth retrying, rate limits, server errors and errors which did not come from the API.
+func isTransient(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return true
+	}
+	return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError
+}
+
+//isNotFound returns true if the API answered with 404 Not Found.
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	return ok && apiErr.Code == http.StatusNotFound
+}
+
+//retry calls fn until it succeeds or fails with an error which is not transient, backing off exponentially between the attempts.
+func (client GCloudClient) retry(fn func() error) error {
+	backoff := wait.Backoff{
+		Duration: time.Millisecond * time.Duration(client.cp.GetInt(config.GCloudBackoffMs)),
+		Factor:   2,
+		Steps:    client.cp.GetInt(config.GCloudRetries) + 1,
+	}
+
+	var err error
+	wait.ExponentialBackoff(backoff, func() (bool, error) {
+		err = fn()
+		if err != nil && isTransient(err) {
+			return false, nil
+		}
+		return true, nil
+	})
+	return err
+}
+
+//waitForZoneOperation polls the zonal operation until it is done, and returns the errors it failed with.
+//Transient errors getting the operation are retried until the context is cancelled.
+func (client GCloudClient) waitForZoneOperation(ctx context.Context, zone, operationID string) error {
+	ticker := time.NewTicker(zoneOperationPollInterval)
+	defer ticker.Stop()
+
+	for {
+		result, err := client.computeServiceComputeScope.ZoneOperations.Get(client.project, zone, operationID).Context(ctx).Do()
+		if err != nil && !isTransient(err) {
+			return fmt.Errorf("ZoneOperations.Get: %s", err)
+		}
+		if err == nil && result.Status == "DONE" {
+			return operationError(result)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for operation %s to complete", operationID)
+		case <-ticker.C:
+		}
+	}
+}
+
+//operationError returns the errors of the done operation as one error, nil if it succeeded.
+func operationError(op *compute.Operation) error {
+	if op.Error == nil || len(op.Error.Errors) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(op.Error.Errors))
+	for _, opErr := range op.Error.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", opErr.Code, opErr.Message))
+	}
+	return fmt.Errorf("operation %s failed: %s", op.Name, strings.Join(messages, config.CommaSeparater))
+}
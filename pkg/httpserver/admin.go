@@ -0,0 +1,189 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/killer"
+	"github.com/roppenlabs/silent-assassin/pkg/pause"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	actionReschedule = "reschedule"
+	actionKill       = "kill"
+	actionExclude    = "exclude"
+	actionPause      = "pause"
+	actionResume     = "resume"
+)
+
+//AdminRequest is the body of the admin endpoints. ExpiryTime is only read by the reschedule endpoint,
+//Until by the pause endpoint, User and Reason are recorded in the audit log.
+type AdminRequest struct {
+	ExpiryTime *time.Time `json:"expiryTime,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	User       string     `json:"user"`
+	Reason     string     `json:"reason"`
+}
+
+//AdminResponse is the outcome of an admin action on a node.
+type AdminResponse struct {
+	Node       string     `json:"node"`
+	Action     string     `json:"action"`
+	ExpiryTime *time.Time `json:"expiryTime,omitempty"`
+	Deadline   *time.Time `json:"deadline,omitempty"`
+}
+
+//auditEntry records an admin action, whether it succeeded or not.
+type auditEntry struct {
+	Time       time.Time  `json:"time"`
+	Action     string     `json:"action"`
+	Node       string     `json:"node,omitempty"`
+	User       string     `json:"user,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	ExpiryTime *time.Time `json:"expiryTime,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	RemoteAddr string     `json:"remoteAddr"`
+	Error      string     `json:"error,omitempty"`
+}
+
+//authenticate only lets through the requests with the bearer token of ADMIN.TOKEN.
+//The admin endpoints are disabled when no token is configured.
+func (s Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := s.cp.GetString(config.AdminToken)
+		if token == "" {
+			s.writeStatus(w, http.StatusForbidden, errorResponse{Error: "the admin API is disabled, ADMIN.TOKEN is not set"})
+			return
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			s.logger.Warn(fmt.Sprintf("Unauthorized admin request %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr))
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			s.writeStatus(w, http.StatusUnauthorized, errorResponse{Error: "invalid or missing bearer token"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+//handleReschedule sets the expiry time of the node within its safe lifetime.
+func (s Server) handleReschedule(w http.ResponseWriter, r *http.Request) {
+	req, entry, ok := s.readAdminRequest(w, r, actionReschedule)
+	if !ok {
+		return
+	}
+	if req.ExpiryTime == nil {
+		s.rejectAdminRequest(w, entry, http.StatusBadRequest, errors.New("expiryTime is required"))
+		return
+	}
+	entry.ExpiryTime = req.ExpiryTime
+
+	deadline, err := s.killer.Reschedule(entry.Node, *req.ExpiryTime)
+	if err != nil {
+		s.rejectAdminRequest(w, entry, adminErrorStatus(err), err)
+		return
+	}
+	s.audit(entry)
+	s.writeStatus(w, http.StatusOK, AdminResponse{Node: entry.Node, Action: entry.Action, ExpiryTime: req.ExpiryTime, Deadline: &deadline})
+}
+
+//handleKill makes the node expire now, the Killer drains and deletes it right away when it runs on this replica,
+//or on its next poll on the leader. The drain is asynchronous, so the request is only accepted.
+func (s Server) handleKill(w http.ResponseWriter, r *http.Request) {
+	_, entry, ok := s.readAdminRequest(w, r, actionKill)
+	if !ok {
+		return
+	}
+	if err := s.killer.Expedite(entry.Node); err != nil {
+		s.rejectAdminRequest(w, entry, adminErrorStatus(err), err)
+		return
+	}
+	s.audit(entry)
+	s.writeStatus(w, http.StatusAccepted, AdminResponse{Node: entry.Node, Action: entry.Action})
+}
+
+//handleExclude cancels the kill of the node and keeps the Spotter from scheduling a new one.
+func (s Server) handleExclude(w http.ResponseWriter, r *http.Request) {
+	_, entry, ok := s.readAdminRequest(w, r, actionExclude)
+	if !ok {
+		return
+	}
+	if err := s.killer.Exclude(entry.Node); err != nil {
+		s.rejectAdminRequest(w, entry, adminErrorStatus(err), err)
+		return
+	}
+	s.audit(entry)
+	s.writeStatus(w, http.StatusOK, AdminResponse{Node: entry.Node, Action: entry.Action})
+}
+
+//readAdminRequest decodes the optional body of an admin request, it writes the response and returns false if it is invalid.
+func (s Server) readAdminRequest(w http.ResponseWriter, r *http.Request, action string) (AdminRequest, auditEntry, bool) {
+	var req AdminRequest
+	entry := auditEntry{Time: time.Now(), Action: action, Node: mux.Vars(r)["name"], RemoteAddr: r.RemoteAddr}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.rejectAdminRequest(w, entry, http.StatusBadRequest, fmt.Errorf("invalid request body %s", err.Error()))
+			return req, entry, false
+		}
+	}
+	entry.User = req.User
+	entry.Reason = req.Reason
+	return req, entry, true
+}
+
+//rejectAdminRequest audits the failed action and writes the error.
+func (s Server) rejectAdminRequest(w http.ResponseWriter, entry auditEntry, status int, err error) {
+	entry.Error = err.Error()
+	s.audit(entry)
+	s.writeStatus(w, status, errorResponse{Error: err.Error()})
+}
+
+//audit writes the action to the audit log, a log line starting with "Audit:", and sends it to the notifier.
+func (s Server) audit(entry auditEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Error encoding the audit entry %s", err.Error()))
+	}
+	s.logger.Info(fmt.Sprintf("Audit: %s", line))
+
+	details := entry.Action
+	if entry.Node != "" {
+		details += fmt.Sprintf(" of node %s", entry.Node)
+	}
+	if entry.ExpiryTime != nil {
+		details += fmt.Sprintf(" to %s", entry.ExpiryTime.Format(time.RFC1123Z))
+	}
+	if entry.Until != nil {
+		details += fmt.Sprintf(" until %s", entry.Until.Format(time.RFC1123Z))
+	}
+	details += fmt.Sprintf(" by %s from %s", entry.User, entry.RemoteAddr)
+	if entry.Reason != "" {
+		details += fmt.Sprintf(", reason: %s", entry.Reason)
+	}
+	if entry.Error != "" {
+		s.notifier.Error(config.EventAdmin, fmt.Sprintf("Rejected %s\nError:%s", details, entry.Error))
+		return
+	}
+	s.notifier.Info(config.EventAdmin, details)
+}
+
+//adminErrorStatus returns the HTTP status of an error of an admin action.
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, killer.ErrOutsideLifetime), errors.Is(err, pause.ErrUntilInPast):
+		return http.StatusBadRequest
+	case errors.Is(err, killer.ErrNodeInFlight):
+		return http.StatusConflict
+	case apierrors.IsNotFound(err), errors.Is(err, killer.ErrNodeNotManaged):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
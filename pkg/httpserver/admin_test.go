@@ -0,0 +1,188 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
+	"github.com/roppenlabs/silent-assassin/pkg/gcloud"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	"github.com/roppenlabs/silent-assassin/pkg/killer"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/pause"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+//recordingNotifier records the notifications, the NotifierClientMock does not.
+type recordingNotifier struct {
+	infos  []string
+	errors []string
+}
+
+func (n *recordingNotifier) Info(event, details string) {
+	n.infos = append(n.infos, event+": "+details)
+}
+
+func (n *recordingNotifier) Error(event, details string) {
+	n.errors = append(n.errors, event+": "+details)
+}
+
+func (n *recordingNotifier) Route(channel string) notifier.INotifierClient {
+	return n
+}
+
+type AdminTestSuite struct {
+	suite.Suite
+	configFile string
+	k8sMock    *k8s.K8sClientMock
+	notifier   *recordingNotifier
+	server     *Server
+}
+
+func (at *AdminTestSuite) SetupTest() {
+	file, err := ioutil.TempFile("", "application*.yaml")
+	if err != nil {
+		at.T().Fatal(err)
+	}
+	file.WriteString("ADMIN:\n  TOKEN: s3cr3t\nKILLER:\n  DRAINING_TIMEOUT_WHEN_NODE_EXPIRED_MS: 3600000\nLOGGER:\n  LEVEL: error\n")
+	file.Close()
+	at.configFile = file.Name()
+
+	cp := config.Init(at.configFile)
+	zl := logger.Init(cp)
+	at.k8sMock = new(k8s.K8sClientMock)
+	at.notifier = &recordingNotifier{}
+	ks := killer.NewKillerService(cp, zl, at.k8sMock, new(gcloud.GCloudClientMock), at.notifier, disruption.NewCoordinator(cp, zl), nil, nil)
+	at.server = New(cp, zl, ks, at.k8sMock, at.notifier, nil, pause.NewPauseService(cp, zl, at.k8sMock, at.notifier))
+	at.server.setRoutes()
+}
+
+func (at *AdminTestSuite) TearDownTest() {
+	os.Remove(at.configFile)
+}
+
+func (at *AdminTestSuite) post(uri, token string, body interface{}) *httptest.ResponseRecorder {
+	content, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, uri, bytes.NewReader(content))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	at.server.apiServer.Handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func (at *AdminTestSuite) node(name string, annotations map[string]string) v1.Node {
+	return v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:              name,
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+		Labels:            map[string]string{"cloud.google.com/gke-preemptible": "true"},
+		Annotations:       annotations,
+	}}
+}
+
+func (at *AdminTestSuite) TestShouldRejectRequestsWithoutToken() {
+	for _, token := range []string{"", "wrong"} {
+		recorder := at.post("/api/v1/nodes/node-1/kill", token, AdminRequest{})
+		assert.Equal(at.T(), http.StatusUnauthorized, recorder.Code)
+	}
+	at.k8sMock.AssertNotCalled(at.T(), "GetNode", mock.Anything)
+	assert.Empty(at.T(), at.notifier.infos)
+	assert.Empty(at.T(), at.notifier.errors)
+}
+
+func (at *AdminTestSuite) TestShouldRescheduleAndAuditNode() {
+	at.k8sMock.On("GetNode", "node-1").Return(at.node("node-1", map[string]string{config.StateAnnotation: "scheduled"}), nil)
+	at.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+
+	expiry := time.Now().Add(5 * time.Hour).Truncate(time.Second)
+	recorder := at.post("/api/v1/nodes/node-1/reschedule", "s3cr3t", AdminRequest{ExpiryTime: &expiry, User: "alice", Reason: "incident-42"})
+	assert.Equal(at.T(), http.StatusOK, recorder.Code)
+
+	var response AdminResponse
+	assert.Nil(at.T(), json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(at.T(), "node-1", response.Node)
+	assert.True(at.T(), expiry.Equal(*response.ExpiryTime))
+	assert.Equal(at.T(), []string{"ADMIN: reschedule of node node-1 to " + expiry.Format(time.RFC1123Z) + " by alice from 192.0.2.1:1234, reason: incident-42"}, at.notifier.infos)
+
+	tooLate := time.Now().Add(30 * time.Hour)
+	recorder = at.post("/api/v1/nodes/node-1/reschedule", "s3cr3t", AdminRequest{ExpiryTime: &tooLate})
+	assert.Equal(at.T(), http.StatusBadRequest, recorder.Code)
+	assert.Equal(at.T(), 1, len(at.notifier.errors))
+	assert.True(at.T(), strings.HasPrefix(at.notifier.errors[0], "ADMIN: Rejected reschedule of node node-1"))
+
+	recorder = at.post("/api/v1/nodes/node-1/reschedule", "s3cr3t", AdminRequest{})
+	assert.Equal(at.T(), http.StatusBadRequest, recorder.Code)
+	at.k8sMock.AssertNumberOfCalls(at.T(), "UpdateNode", 1)
+}
+
+func (at *AdminTestSuite) TestShouldAcceptKillAndExcludeNodes() {
+	at.k8sMock.On("GetNode", "node-1").Return(at.node("node-1", nil), nil)
+	at.k8sMock.On("GetNode", "node-2").Return(at.node("node-2", map[string]string{config.StateAnnotation: "draining"}), nil)
+	at.k8sMock.On("GetNode", "node-3").Return(v1.Node{}, apierrors.NewNotFound(schema.GroupResource{Resource: "nodes"}, "node-3"))
+	at.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+
+	assert.Equal(at.T(), http.StatusAccepted, at.post("/api/v1/nodes/node-1/kill", "s3cr3t", AdminRequest{User: "bob"}).Code)
+	assert.Equal(at.T(), http.StatusOK, at.post("/api/v1/nodes/node-1/exclude", "s3cr3t", nil).Code)
+	assert.Equal(at.T(), http.StatusConflict, at.post("/api/v1/nodes/node-2/kill", "s3cr3t", nil).Code)
+	assert.Equal(at.T(), http.StatusNotFound, at.post("/api/v1/nodes/node-3/exclude", "s3cr3t", nil).Code)
+	at.k8sMock.AssertNumberOfCalls(at.T(), "UpdateNode", 2)
+}
+
+func (at *AdminTestSuite) TestShouldRejectNodesNotMatchingTheNodeSelectors() {
+	node := at.node("node-1", nil)
+	node.Labels = map[string]string{"cloud.google.com/gke-preemptible": "false"}
+	at.k8sMock.On("GetNode", "node-1").Return(node, nil)
+
+	expiry := time.Now().Add(5 * time.Hour)
+	assert.Equal(at.T(), http.StatusNotFound, at.post("/api/v1/nodes/node-1/reschedule", "s3cr3t", AdminRequest{ExpiryTime: &expiry}).Code)
+	assert.Equal(at.T(), http.StatusNotFound, at.post("/api/v1/nodes/node-1/kill", "s3cr3t", nil).Code)
+	assert.Equal(at.T(), http.StatusNotFound, at.post("/api/v1/nodes/node-1/exclude", "s3cr3t", nil).Code)
+	assert.Equal(at.T(), 3, len(at.notifier.errors))
+	at.k8sMock.AssertNotCalled(at.T(), "UpdateNode", mock.Anything)
+}
+
+func (at *AdminTestSuite) TestShouldPauseAndResumeDisruptions() {
+	at.k8sMock.On("GetConfigMap", "silent-assassin-pause", "default").Return(v1.ConfigMap{}, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "silent-assassin-pause")).Twice()
+	at.k8sMock.On("CreateConfigMap", mock.Anything).Return(nil)
+
+	past := time.Now().Add(-time.Hour)
+	assert.Equal(at.T(), http.StatusBadRequest, at.post("/api/v1/pause", "s3cr3t", AdminRequest{Until: &past}).Code)
+
+	until := time.Now().Add(6 * time.Hour).Truncate(time.Second)
+	recorder := at.post("/api/v1/pause", "s3cr3t", AdminRequest{Until: &until, User: "alice", Reason: "cluster upgrade"})
+	assert.Equal(at.T(), http.StatusOK, recorder.Code)
+	var state pause.State
+	assert.Nil(at.T(), json.Unmarshal(recorder.Body.Bytes(), &state))
+	assert.True(at.T(), state.Paused)
+	assert.True(at.T(), until.Equal(*state.Until))
+	assert.Equal(at.T(), "ADMIN: pause until "+until.Format(time.RFC1123Z)+" by alice from 192.0.2.1:1234, reason: cluster upgrade", at.notifier.infos[0])
+
+	created := at.k8sMock.Calls[len(at.k8sMock.Calls)-1].Arguments.Get(0).(v1.ConfigMap)
+	at.k8sMock.On("GetConfigMap", "silent-assassin-pause", "default").Return(created, nil)
+	at.k8sMock.On("UpdateConfigMap", mock.Anything).Return(nil)
+
+	recorder = at.post("/api/v1/resume", "s3cr3t", AdminRequest{User: "alice"})
+	assert.Equal(at.T(), http.StatusOK, recorder.Code)
+	assert.Equal(at.T(), "false", at.k8sMock.Calls[len(at.k8sMock.Calls)-2].Arguments.Get(0).(v1.ConfigMap).Data["paused"])
+	assert.Equal(at.T(), "ADMIN: resume by alice from 192.0.2.1:1234", at.notifier.infos[1])
+}
+
+func TestAdminTestSuite(t *testing.T) {
+	suite.Run(t, new(AdminTestSuite))
+}
@@ -0,0 +1,167 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/killer"
+	"github.com/roppenlabs/silent-assassin/pkg/shifter"
+	"github.com/roppenlabs/silent-assassin/pkg/state"
+	v1 "k8s.io/api/core/v1"
+)
+
+//NodeInfo is a node managed by silent-assassin, as read from its labels and annotations.
+type NodeInfo struct {
+	Name              string     `json:"name"`
+	NodePool          string     `json:"nodePool"`
+	Zone              string     `json:"zone"`
+	CreationTime      time.Time  `json:"creationTime"`
+	ExpiryTime        *time.Time `json:"expiryTime,omitempty"`
+	PostponeKillUntil *time.Time `json:"postponeKillUntil,omitempty"`
+	Policy            string     `json:"policy,omitempty"`
+	State             string     `json:"state,omitempty"`
+	SkipExpiry        bool       `json:"skipExpiry"`
+	Unschedulable     bool       `json:"unschedulable"`
+}
+
+//ScheduledKill is the time at which the Killer drains a node, the earliest being its expiry time.
+//A kill is postponed at most until the deadline, the drain timeout of the policy of the node before the end of its 24 hours.
+type ScheduledKill struct {
+	Node              string     `json:"node"`
+	NodePool          string     `json:"nodePool"`
+	Zone              string     `json:"zone"`
+	Policy            string     `json:"policy,omitempty"`
+	State             string     `json:"state,omitempty"`
+	KillTime          time.Time  `json:"killTime"`
+	ExpiryTime        time.Time  `json:"expiryTime"`
+	PostponeKillUntil *time.Time `json:"postponeKillUntil,omitempty"`
+	Deadline          time.Time  `json:"deadline"`
+}
+
+//ShifterStatus is the Status of the Shifter of the replica serving the request.
+type ShifterStatus struct {
+	Enabled bool `json:"enabled"`
+	shifter.Status
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+//handleNodes lists the managed nodes, by name.
+func (s Server) handleNodes(w http.ResponseWriter, r *http.Request) {
+	nodes, err := s.kubeClient.GetNodes(s.cp.GetString(config.NodeSelectors))
+	if err != nil {
+		s.writeError(w, fmt.Errorf("error fetching the nodes %s", err.Error()))
+		return
+	}
+
+	infos := make([]NodeInfo, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		infos = append(infos, NodeInfo{
+			Name:              node.Name,
+			NodePool:          node.Labels[s.cp.GetString(config.NodePoolLabel)],
+			Zone:              node.Labels[config.ZoneLabel],
+			CreationTime:      node.CreationTimestamp.Time,
+			ExpiryTime:        annotationTime(node, config.ExpiryTimeAnnotation),
+			PostponeKillUntil: annotationTime(node, config.PostponeKillUntilAnnotation),
+			Policy:            node.Annotations[config.PolicyAnnotation],
+			State:             state.Of(node),
+			SkipExpiry:        node.Annotations[config.SkipExpiryAnnotation] == "true",
+			Unschedulable:     node.Spec.Unschedulable,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	s.writeJSON(w, infos)
+}
+
+//handleSchedule lists the kills of the managed nodes with an expiry time, the next kill first.
+func (s Server) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	nodes, err := s.kubeClient.GetNodes(s.cp.GetString(config.NodeSelectors))
+	if err != nil {
+		s.writeError(w, fmt.Errorf("error fetching the nodes %s", err.Error()))
+		return
+	}
+
+	policies, err := s.killer.Policies()
+	if err != nil {
+		s.writeError(w, fmt.Errorf("error loading the policies %s", err.Error()))
+		return
+	}
+
+	kills := []ScheduledKill{}
+	for _, node := range nodes.Items {
+		expiry := annotationTime(node, config.ExpiryTimeAnnotation)
+		if expiry == nil {
+			continue
+		}
+		kill := ScheduledKill{
+			Node:              node.Name,
+			NodePool:          node.Labels[s.cp.GetString(config.NodePoolLabel)],
+			Zone:              node.Labels[config.ZoneLabel],
+			Policy:            node.Annotations[config.PolicyAnnotation],
+			State:             state.Of(node),
+			KillTime:          *expiry,
+			ExpiryTime:        *expiry,
+			PostponeKillUntil: annotationTime(node, config.PostponeKillUntilAnnotation),
+			Deadline:          killer.NodeDeadline(node, policies.ForAnnotatedNode(node).DrainingTimeoutMs),
+		}
+		if kill.PostponeKillUntil != nil && kill.PostponeKillUntil.After(kill.KillTime) {
+			kill.KillTime = *kill.PostponeKillUntil
+			if kill.KillTime.After(kill.Deadline) {
+				kill.KillTime = kill.Deadline
+			}
+		}
+		kills = append(kills, kill)
+	}
+	sort.Slice(kills, func(i, j int) bool {
+		if kills[i].KillTime.Equal(kills[j].KillTime) {
+			return kills[i].Node < kills[j].Node
+		}
+		return kills[i].KillTime.Before(kills[j].KillTime)
+	})
+	s.writeJSON(w, kills)
+}
+
+//handleShifter shows the status of the Shifter. Only the leader runs the Shifter, the other replicas report it as not running.
+func (s Server) handleShifter(w http.ResponseWriter, r *http.Request) {
+	status := ShifterStatus{Enabled: s.shifter != nil}
+	if s.shifter != nil {
+		status.Status = s.shifter.Status()
+	}
+	s.writeJSON(w, status)
+}
+
+//annotationTime returns the time of an RFC1123Z annotation of the node, nil if it is not set or invalid.
+func annotationTime(node v1.Node, annotation string) *time.Time {
+	timestamp, ok := node.Annotations[annotation]
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC1123Z, timestamp)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func (s Server) writeJSON(w http.ResponseWriter, body interface{}) {
+	s.writeStatus(w, http.StatusOK, body)
+}
+
+func (s Server) writeStatus(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Error(fmt.Sprintf("Error encoding the response %s", err.Error()))
+	}
+}
+
+func (s Server) writeError(w http.ResponseWriter, err error) {
+	s.logger.Error(err.Error())
+	s.writeStatus(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,164 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	"github.com/roppenlabs/silent-assassin/pkg/killer"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/pause"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
+	"github.com/roppenlabs/silent-assassin/pkg/shifter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type shifterStatusMock struct {
+	status shifter.Status
+}
+
+func (m shifterStatusMock) Status() shifter.Status {
+	return m.status
+}
+
+type APITestSuite struct {
+	suite.Suite
+	configMock   *config.ProviderMock
+	k8sMock      *k8s.K8sClientMock
+	notifierMock *notifier.NotifierClientMock
+	created      time.Time
+}
+
+func (at *APITestSuite) SetupTest() {
+	at.configMock = new(config.ProviderMock)
+	at.k8sMock = new(k8s.K8sClientMock)
+	at.notifierMock = new(notifier.NotifierClientMock)
+	at.configMock.On("GetString", config.NodeSelectors).Return("cloud.google.com/gke-preemptible=true")
+	at.configMock.On("GetString", config.SpotterWhiteListTimezone).Return("UTC")
+	at.configMock.On("GetString", config.NodePoolLabel).Return("example.com/pool")
+	at.configMock.On("GetString", mock.Anything).Return("debug")
+	at.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"00:00-06:00"})
+	at.configMock.On("GetStringMapString", config.SpotterWhiteListDayIntervalHours).Return(map[string]string{})
+	at.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		policies := args.Get(1).(*[]policy.Policy)
+		*policies = []policy.Policy{{Name: "batch", DrainingTimeoutMs: 7200000}}
+	})
+	at.configMock.On("GetInt32", mock.Anything).Return(int32(8080))
+	at.configMock.On("GetUint32", config.KillerDrainingTimeoutWhenNodeExpiredMs).Return(uint32(3600000))
+	at.created = time.Date(2020, 11, 10, 10, 0, 0, 0, time.UTC)
+}
+
+func (at *APITestSuite) node(name, expiry, postpone string) v1.Node {
+	annotations := map[string]string{config.PolicyAnnotation: "default", config.StateAnnotation: "scheduled"}
+	if expiry != "" {
+		annotations[config.ExpiryTimeAnnotation] = expiry
+	}
+	if postpone != "" {
+		annotations[config.PostponeKillUntilAnnotation] = postpone
+	}
+	return v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:              name,
+		CreationTimestamp: metav1.NewTime(at.created),
+		Labels:            map[string]string{config.NodePoolNameLabel: "services-p-1", "example.com/pool": "services", config.ZoneLabel: "asia-south1-a"},
+		Annotations:       annotations,
+	}}
+}
+
+func (at *APITestSuite) get(shs shifter.IStatusReporter, uri string, body interface{}) int {
+	zl := logger.Init(at.configMock)
+	ks := killer.NewKillerService(at.configMock, zl, at.k8sMock, nil, at.notifierMock, nil, nil, nil)
+	s := New(at.configMock, zl, ks, at.k8sMock, at.notifierMock, shs, pause.PauseService{})
+	s.setRoutes()
+	recorder := httptest.NewRecorder()
+	s.apiServer.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, uri, nil))
+	assert.Equal(at.T(), "application/json", recorder.Header().Get("Content-Type"))
+	assert.Nil(at.T(), json.Unmarshal(recorder.Body.Bytes(), body))
+	return recorder.Code
+}
+
+func (at *APITestSuite) TestShouldListManagedNodes() {
+	at.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true").Return(&v1.NodeList{Items: []v1.Node{
+		at.node("node-2", "", ""),
+		at.node("node-1", "Wed, 11 Nov 2020 03:00:00 +0530", ""),
+	}}, nil)
+
+	var nodes []NodeInfo
+	assert.Equal(at.T(), http.StatusOK, at.get(nil, config.NodesURI, &nodes))
+
+	expiry := time.Date(2020, 11, 10, 21, 30, 0, 0, time.UTC)
+	assert.Equal(at.T(), 2, len(nodes))
+	assert.Equal(at.T(), "node-1", nodes[0].Name)
+	assert.Equal(at.T(), "services", nodes[0].NodePool, "The nodepool should be read from the configured label")
+	assert.Equal(at.T(), "asia-south1-a", nodes[0].Zone)
+	assert.Equal(at.T(), "default", nodes[0].Policy)
+	assert.Equal(at.T(), "scheduled", nodes[0].State)
+	assert.True(at.T(), at.created.Equal(nodes[0].CreationTime))
+	assert.True(at.T(), expiry.Equal(*nodes[0].ExpiryTime))
+	assert.Nil(at.T(), nodes[1].ExpiryTime)
+}
+
+func (at *APITestSuite) TestShouldListKillsInOrderAndCapPostponementsAtDeadline() {
+	at.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true").Return(&v1.NodeList{Items: []v1.Node{
+		at.node("node-1", "Wed, 11 Nov 2020 03:00:00 +0000", ""),
+		at.node("node-2", "Wed, 11 Nov 2020 01:00:00 +0000", "Wed, 11 Nov 2020 12:00:00 +0000"),
+		at.node("node-3", "Wed, 11 Nov 2020 02:00:00 +0000", "Wed, 11 Nov 2020 01:00:00 +0000"),
+		at.node("node-4", "", ""),
+	}}, nil)
+
+	var kills []ScheduledKill
+	assert.Equal(at.T(), http.StatusOK, at.get(nil, config.ScheduleURI, &kills))
+
+	assert.Equal(at.T(), 3, len(kills))
+	assert.Equal(at.T(), "node-3", kills[0].Node)
+	assert.True(at.T(), time.Date(2020, 11, 11, 2, 0, 0, 0, time.UTC).Equal(kills[0].KillTime))
+	assert.Equal(at.T(), "node-1", kills[1].Node)
+	// The kill of node-2 is postponed until its deadline, an hour of drain timeout before its 24 hours.
+	assert.Equal(at.T(), "node-2", kills[2].Node)
+	assert.True(at.T(), time.Date(2020, 11, 11, 9, 0, 0, 0, time.UTC).Equal(kills[2].KillTime))
+	assert.True(at.T(), kills[2].Deadline.Equal(kills[2].KillTime))
+}
+
+func (at *APITestSuite) TestShouldComputeDeadlineWithTheDrainTimeoutOfThePolicy() {
+	node := at.node("node-1", "Wed, 11 Nov 2020 01:00:00 +0000", "Wed, 11 Nov 2020 12:00:00 +0000")
+	node.Annotations[config.PolicyAnnotation] = "batch"
+	at.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true").Return(&v1.NodeList{Items: []v1.Node{node}}, nil)
+
+	var kills []ScheduledKill
+	assert.Equal(at.T(), http.StatusOK, at.get(nil, config.ScheduleURI, &kills))
+
+	// The batch policy drains in two hours, so its deadline is two hours before the 24 hours of the node.
+	assert.Equal(at.T(), 1, len(kills))
+	assert.True(at.T(), time.Date(2020, 11, 11, 8, 0, 0, 0, time.UTC).Equal(kills[0].Deadline))
+	assert.True(at.T(), kills[0].Deadline.Equal(kills[0].KillTime))
+}
+
+func (at *APITestSuite) TestShouldReportErrorFetchingNodes() {
+	at.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true").Return(&v1.NodeList{}, errors.New("connection refused"))
+
+	var response errorResponse
+	assert.Equal(at.T(), http.StatusInternalServerError, at.get(nil, config.ScheduleURI, &response))
+	assert.Equal(at.T(), "error fetching the nodes connection refused", response.Error)
+}
+
+func (at *APITestSuite) TestShouldShowShifterStatus() {
+	var status ShifterStatus
+	assert.Equal(at.T(), http.StatusOK, at.get(nil, config.ShifterURI, &status))
+	assert.Equal(at.T(), ShifterStatus{}, status)
+
+	assert.Equal(at.T(), http.StatusOK, at.get(shifterStatusMock{shifter.Status{Running: true, ShiftingNode: "node-1", ShiftedNodes: 2}}, config.ShifterURI, &status))
+	assert.Equal(at.T(), ShifterStatus{Enabled: true, Status: shifter.Status{Running: true, ShiftingNode: "node-1", ShiftedNodes: 2}}, status)
+}
+
+func TestAPITestSuite(t *testing.T) {
+	suite.Run(t, new(APITestSuite))
+}
@@ -0,0 +1,111 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+//evacuationRetention is how long a finished evacuation can still be read by its ID.
+const evacuationRetention = time.Hour
+
+const (
+	EvacuationRunning   = "running"
+	EvacuationSucceeded = "succeeded"
+	EvacuationFailed    = "failed"
+)
+
+//Evacuation is the operation evacuating the pods of a preempted node, run in the background of its request.
+type Evacuation struct {
+	ID        string     `json:"id"`
+	Node      string     `json:"node"`
+	Status    string     `json:"status"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	nodeKey   string
+}
+
+//evacuations keeps the evacuations of this replica by ID, with the last one of every node,
+//so a repeated request for a node gets the running or succeeded evacuation instead of a new one.
+//Nodes are told apart by their UID too, as a recreated preemptible VM registers again under the same name.
+type evacuations struct {
+	sync.Mutex
+	byID   map[string]*Evacuation
+	byNode map[string]string
+}
+
+func newEvacuations() *evacuations {
+	return &evacuations{
+		byID:   make(map[string]*Evacuation),
+		byNode: make(map[string]string),
+	}
+}
+
+//start returns a new running evacuation of the node and true, or the evacuation of the node
+//and false if one is running or succeeded. A failed evacuation is started again.
+func (e *evacuations) start(node v1.Node) (Evacuation, bool) {
+	e.Lock()
+	defer e.Unlock()
+	e.prune(time.Now())
+
+	key := node.Name + "/" + string(node.UID)
+	if id, ok := e.byNode[key]; ok && e.byID[id].Status != EvacuationFailed {
+		return *e.byID[id], false
+	}
+	evacuation := &Evacuation{ID: newEvacuationID(), Node: node.Name, Status: EvacuationRunning, StartTime: time.Now(), nodeKey: key}
+	e.byID[evacuation.ID] = evacuation
+	e.byNode[key] = evacuation.ID
+	return *evacuation, true
+}
+
+//finish records the outcome of the evacuation.
+func (e *evacuations) finish(id string, err error) Evacuation {
+	e.Lock()
+	defer e.Unlock()
+
+	evacuation := e.byID[id]
+	now := time.Now()
+	evacuation.EndTime = &now
+	evacuation.Status = EvacuationSucceeded
+	if err != nil {
+		evacuation.Status = EvacuationFailed
+		evacuation.Error = err.Error()
+	}
+	return *evacuation
+}
+
+func (e *evacuations) get(id string) (Evacuation, bool) {
+	e.Lock()
+	defer e.Unlock()
+
+	evacuation, ok := e.byID[id]
+	if !ok {
+		return Evacuation{}, false
+	}
+	return *evacuation, true
+}
+
+//prune forgets the evacuations finished for longer than the retention.
+func (e *evacuations) prune(now time.Time) {
+	for id, evacuation := range e.byID {
+		if evacuation.EndTime != nil && now.Sub(*evacuation.EndTime) > evacuationRetention {
+			delete(e.byID, id)
+			if e.byNode[evacuation.nodeKey] == id {
+				delete(e.byNode, evacuation.nodeKey)
+			}
+		}
+	}
+}
+
+//newEvacuationID returns a random ID of 16 hexadecimal characters.
+func newEvacuationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
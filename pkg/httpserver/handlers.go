@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/gorilla/mux"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 type NodeTerminationRequest struct {
@@ -13,30 +16,66 @@ type NodeTerminationRequest struct {
 }
 
 //handlePreemption handles POST request on EvacuatePodsURI. This deletes the pods on the node requested.
+//The pods are deleted in the background, so the request is answered with the Evacuation right away,
+//well within the 30 seconds of the preemption. A node being or already evacuated gets its running Evacuation.
 func (s Server) handleTermination(w http.ResponseWriter, r *http.Request) {
 	var nodeTerminationRequest NodeTerminationRequest
 	if err := json.NewDecoder(r.Body).Decode(&nodeTerminationRequest); err != nil {
 		s.logger.Error(fmt.Sprintf("Error decoding the request body %s", err.Error()))
-		w.WriteHeader(http.StatusBadRequest)
+		s.writeStatus(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request body %s", err.Error())})
+		return
+	}
+	if nodeTerminationRequest.Name == "" {
+		s.writeStatus(w, http.StatusBadRequest, errorResponse{Error: "the name of the node is required"})
 		return
 	}
 
 	node, err := s.killer.GetNode(nodeTerminationRequest.Name)
-
-	if err != nil {
+	if apierrors.IsNotFound(err) {
 		s.logger.Error(fmt.Sprintf("Error fetching the node %s, %s", nodeTerminationRequest.Name, err.Error()))
-		w.WriteHeader(http.StatusInternalServerError)
+		s.writeStatus(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		s.writeError(w, fmt.Errorf("Error fetching the node %s, %s", nodeTerminationRequest.Name, err.Error()))
+		return
+	}
+
+	evacuation, started := s.evacuations.start(node)
+	w.Header().Set("Location", evacuationURI(evacuation.ID))
+	if !started {
+		s.logger.Info(fmt.Sprintf("Evacuation %s of node %s is already %s", evacuation.ID, node.Name, evacuation.Status))
+		s.writeStatus(w, http.StatusAccepted, evacuation)
 		return
 	}
-	nodePool := node.Labels[s.cp.GetString(config.NodePoolLabel)]
 
+	nodePool := node.Labels[s.cp.GetString(config.NodePoolLabel)]
 	nodesPreempted.WithLabelValues(nodePool).Inc()
-	err = s.killer.EvacuatePodsFromNode(nodeTerminationRequest.Name, s.cp.GetUint32(config.KillerDrainingTimeoutWhenNodePreemptedMs), true)
+	s.logger.Info(fmt.Sprintf("Starting evacuation %s of node %s", evacuation.ID, node.Name))
 
-	if err != nil {
-		s.logger.Error(fmt.Sprintf("Error evacuating pods from node %s", node.Name))
-		w.WriteHeader(http.StatusInternalServerError)
+	go func() {
+		err := s.killer.EvacuatePodsFromNode(node.Name, s.cp.GetUint32(config.KillerDrainingTimeoutWhenNodePreemptedMs), true)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Error evacuating pods from node %s, %s", node.Name, err.Error()))
+		}
+		evacuation := s.evacuations.finish(evacuation.ID, err)
+		s.logger.Info(fmt.Sprintf("Evacuation %s of node %s %s", evacuation.ID, node.Name, evacuation.Status))
+	}()
+
+	s.writeStatus(w, http.StatusAccepted, evacuation)
+}
+
+//handleEvacuation returns the Evacuation of the ID. Only the replica which received the request knows it.
+func (s Server) handleEvacuation(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	evacuation, ok := s.evacuations.get(id)
+	if !ok {
+		s.writeStatus(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("evacuation %s not found", id)})
+		return
 	}
+	s.writeJSON(w, evacuation)
+}
 
-	w.WriteHeader(http.StatusNoContent)
+func evacuationURI(id string) string {
+	return strings.Replace(config.EvacuationURI, "{id}", id, 1)
 }
@@ -0,0 +1,122 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
+	"github.com/roppenlabs/silent-assassin/pkg/gcloud"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	"github.com/roppenlabs/silent-assassin/pkg/killer"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/pause"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type EvacuationTestSuite struct {
+	suite.Suite
+	configFile string
+	k8sMock    *k8s.K8sClientMock
+	server     *Server
+}
+
+func (et *EvacuationTestSuite) SetupTest() {
+	file, err := ioutil.TempFile("", "application*.yaml")
+	if err != nil {
+		et.T().Fatal(err)
+	}
+	file.WriteString("LOGGER:\n  LEVEL: error\n")
+	file.Close()
+	et.configFile = file.Name()
+
+	cp := config.Init(et.configFile)
+	zl := logger.Init(cp)
+	nf := &recordingNotifier{}
+	et.k8sMock = new(k8s.K8sClientMock)
+	ks := killer.NewKillerService(cp, zl, et.k8sMock, new(gcloud.GCloudClientMock), nf, disruption.NewCoordinator(cp, zl), nil, nil)
+	et.server = New(cp, zl, ks, et.k8sMock, nf, nil, pause.PauseService{})
+	et.server.setRoutes()
+}
+
+func (et *EvacuationTestSuite) TearDownTest() {
+	os.Remove(et.configFile)
+}
+
+func (et *EvacuationTestSuite) serve(method, uri, body string) (*httptest.ResponseRecorder, Evacuation) {
+	recorder := httptest.NewRecorder()
+	et.server.apiServer.Handler.ServeHTTP(recorder, httptest.NewRequest(method, uri, strings.NewReader(body)))
+	var evacuation Evacuation
+	json.Unmarshal(recorder.Body.Bytes(), &evacuation)
+	return recorder, evacuation
+}
+
+func (et *EvacuationTestSuite) TestShouldEvacuateInBackgroundAndDeduplicateRequests() {
+	release := make(chan time.Time)
+	et.k8sMock.On("GetNode", "node-1").Return(v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}, nil)
+	et.k8sMock.On("UpdateNode", mock.Anything).Return(errors.New("conflict")).WaitUntil(release)
+
+	recorder, evacuation := et.serve(http.MethodPost, "/evacuatepods", `{"Name": "node-1"}`)
+	assert.Equal(et.T(), http.StatusAccepted, recorder.Code)
+	assert.Equal(et.T(), "/evacuatepods/"+evacuation.ID, recorder.Header().Get("Location"))
+	assert.Equal(et.T(), EvacuationRunning, evacuation.Status)
+
+	recorder, repeated := et.serve(http.MethodPost, "/evacuatepods", `{"Name": "node-1"}`)
+	assert.Equal(et.T(), http.StatusAccepted, recorder.Code)
+	assert.Equal(et.T(), evacuation.ID, repeated.ID)
+
+	_, running := et.serve(http.MethodGet, "/evacuatepods/"+evacuation.ID, "")
+	assert.Equal(et.T(), EvacuationRunning, running.Status)
+
+	close(release)
+	assert.Eventually(et.T(), func() bool {
+		_, finished := et.serve(http.MethodGet, "/evacuatepods/"+evacuation.ID, "")
+		return finished.Status == EvacuationFailed && finished.Error == "conflict" && finished.EndTime != nil
+	}, time.Second, 10*time.Millisecond)
+	et.k8sMock.AssertNumberOfCalls(et.T(), "UpdateNode", 1)
+
+	// A failed evacuation is started again.
+	_, retried := et.serve(http.MethodPost, "/evacuatepods", `{"Name": "node-1"}`)
+	assert.NotEqual(et.T(), evacuation.ID, retried.ID)
+}
+
+func (et *EvacuationTestSuite) TestShouldEvacuateNodeRegisteredAgainUnderTheSameName() {
+	evacuations := newEvacuations()
+	first, started := evacuations.start(v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "uid-1"}})
+	assert.True(et.T(), started)
+	evacuations.finish(first.ID, nil)
+
+	_, started = evacuations.start(v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "uid-1"}})
+	assert.False(et.T(), started, "A succeeded evacuation of the same node should not be started again")
+	second, started := evacuations.start(v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "uid-2"}})
+	assert.True(et.T(), started, "The node recreated under the same name should be evacuated")
+	assert.NotEqual(et.T(), first.ID, second.ID)
+}
+
+func (et *EvacuationTestSuite) TestShouldRejectUnknownNodesAndEvacuations() {
+	et.k8sMock.On("GetNode", "node-2").Return(v1.Node{}, apierrors.NewNotFound(schema.GroupResource{Resource: "nodes"}, "node-2"))
+
+	recorder, _ := et.serve(http.MethodPost, "/evacuatepods", `{"Name": "node-2"}`)
+	assert.Equal(et.T(), http.StatusNotFound, recorder.Code)
+	recorder, _ = et.serve(http.MethodPost, "/evacuatepods", `{}`)
+	assert.Equal(et.T(), http.StatusBadRequest, recorder.Code)
+	recorder, _ = et.serve(http.MethodGet, "/evacuatepods/0123456789abcdef", "")
+	assert.Equal(et.T(), http.StatusNotFound, recorder.Code)
+}
+
+func TestEvacuationTestSuite(t *testing.T) {
+	suite.Run(t, new(EvacuationTestSuite))
+}
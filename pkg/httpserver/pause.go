@@ -0,0 +1,47 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+)
+
+//handlePauseStatus returns the pause of the disruptions, with the work deferred by the services of this replica.
+func (s Server) handlePauseStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.pause.Status()
+	if err != nil {
+		s.writeError(w, fmt.Errorf("error reading the pause %s", err.Error()))
+		return
+	}
+	s.writeJSON(w, status)
+}
+
+//handlePause pauses the Killer and the Shifter of every replica, until the optional until time.
+func (s Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	req, entry, ok := s.readAdminRequest(w, r, actionPause)
+	if !ok {
+		return
+	}
+	entry.Until = req.Until
+
+	state, err := s.pause.Pause(req.Until, req.User, req.Reason)
+	if err != nil {
+		s.rejectAdminRequest(w, entry, adminErrorStatus(err), err)
+		return
+	}
+	s.audit(entry)
+	s.writeStatus(w, http.StatusOK, state)
+}
+
+//handleResume ends the pause, the leader reports the work deferred during the pause on its next poll.
+func (s Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	_, entry, ok := s.readAdminRequest(w, r, actionResume)
+	if !ok {
+		return
+	}
+	if err := s.pause.Resume(); err != nil {
+		s.rejectAdminRequest(w, entry, adminErrorStatus(err), err)
+		return
+	}
+	s.audit(entry)
+	s.handlePauseStatus(w, r)
+}
@@ -11,8 +11,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
 	"github.com/roppenlabs/silent-assassin/pkg/killer"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/pause"
+	"github.com/roppenlabs/silent-assassin/pkg/shifter"
 )
 
 var (
@@ -23,14 +27,21 @@ var (
 )
 
 type Server struct {
-	apiServer *http.Server
-	logger    logger.IZapLogger
-	killer    killer.KillerService
-	cp        config.IProvider
+	apiServer   *http.Server
+	logger      logger.IZapLogger
+	killer      killer.KillerService
+	kubeClient  k8s.IKubernetesClient
+	notifier    notifier.INotifierClient
+	shifter     shifter.IStatusReporter
+	pause       pause.PauseService
+	evacuations *evacuations
+	cp          config.IProvider
 }
 
-//NewHttpServer creates new server.
-func New(cp config.IProvider, zapLogger logger.IZapLogger, ks killer.KillerService) *Server {
+//NewHttpServer creates new server. The API reads the nodes through kc, the client of the services,
+//and the status of the Shifter from shs, nil when the Shifter is disabled. The admin actions are sent to nf,
+//the disruptions are paused and resumed through pa.
+func New(cp config.IProvider, zapLogger logger.IZapLogger, ks killer.KillerService, kc k8s.IKubernetesClient, nf notifier.INotifierClient, shs shifter.IStatusReporter, pa pause.PauseService) *Server {
 	host := fmt.Sprintf("%s:%d", cp.GetString(config.ServerListenHost), cp.GetInt32(config.ServerPort))
 
 	srv := &http.Server{
@@ -38,10 +49,15 @@ func New(cp config.IProvider, zapLogger logger.IZapLogger, ks killer.KillerServi
 	}
 
 	return &Server{
-		apiServer: srv,
-		logger:    zapLogger,
-		killer:    ks,
-		cp:        cp,
+		apiServer:   srv,
+		logger:      zapLogger,
+		killer:      ks,
+		kubeClient:  kc,
+		notifier:    nf,
+		shifter:     shs,
+		pause:       pa,
+		evacuations: newEvacuations(),
+		cp:          cp,
 	}
 }
 
@@ -55,6 +71,16 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) {
 func (s *Server) setRoutes() {
 	router := mux.NewRouter()
 	router.HandleFunc(config.EvacuatePodsURI, s.handleTermination).Methods(http.MethodPost)
+	router.HandleFunc(config.EvacuationURI, s.handleEvacuation).Methods(http.MethodGet)
+	router.HandleFunc(config.NodesURI, s.handleNodes).Methods(http.MethodGet)
+	router.HandleFunc(config.ScheduleURI, s.handleSchedule).Methods(http.MethodGet)
+	router.HandleFunc(config.ShifterURI, s.handleShifter).Methods(http.MethodGet)
+	router.HandleFunc(config.RescheduleNodeURI, s.authenticate(s.handleReschedule)).Methods(http.MethodPost)
+	router.HandleFunc(config.KillNodeURI, s.authenticate(s.handleKill)).Methods(http.MethodPost)
+	router.HandleFunc(config.ExcludeNodeURI, s.authenticate(s.handleExclude)).Methods(http.MethodPost)
+	router.HandleFunc(config.PauseURI, s.handlePauseStatus).Methods(http.MethodGet)
+	router.HandleFunc(config.PauseURI, s.authenticate(s.handlePause)).Methods(http.MethodPost)
+	router.HandleFunc(config.ResumeURI, s.authenticate(s.handleResume)).Methods(http.MethodPost)
 	router.Path(config.Metrics).Handler(promhttp.Handler())
 	s.apiServer.Handler = router
 }
@@ -27,6 +27,12 @@ const (
 type node struct {
 	Name string
 }
+
+//evacuation is the operation started by the server to evacuate the node.
+type evacuation struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
 type InformerService struct {
 	logger             logger.IZapLogger
 	pendingTermination chan bool
@@ -97,32 +103,37 @@ func (pns InformerService) informPreemption(nodeName string) {
 		pns.logger.Error(fmt.Sprintf("Error building request %s", err))
 	}
 
-	b := bytes.NewBuffer(data)
-
 	preemptionURI := fmt.Sprintf("%s%s", pns.cp.GetString(config.ServerHost), config.EvacuatePodsURI)
-	req, err := http.NewRequest(http.MethodPost, preemptionURI, b)
-	if err != nil {
-		panic(err.Error())
-	}
-
-	req.Header.Set("Content-type", "application/json")
-
-	var res *http.Response
 
+	// The server evacuates the node in the background and answers 202 with the evacuation right away,
+	// a server older than the client answers 204 once the node is evacuated.
 	for i := 0; i < pns.cp.GetInt(config.ClientServerRetries); i++ {
-		res, err = pns.httpClient.Do(req)
+		req, err := http.NewRequest(http.MethodPost, preemptionURI, bytes.NewReader(data))
+		if err != nil {
+			panic(err.Error())
+		}
+		req.Header.Set("Content-type", "application/json")
+
+		res, err := pns.httpClient.Do(req)
 		if err != nil {
 			pns.logger.Error(fmt.Sprintf("Trial %d: Error calling Server: %v", i+1, err))
 			continue
 		}
-		if res.StatusCode != http.StatusNoContent {
-			pns.logger.Error(fmt.Sprintf("Trial %d: Error calling Server response status %d", i+1, res.StatusCode))
-			continue
+		var e evacuation
+		if res.StatusCode == http.StatusAccepted {
+			json.NewDecoder(res.Body).Decode(&e)
 		}
-		break
-	}
-	if res != nil {
 		res.Body.Close()
+
+		switch res.StatusCode {
+		case http.StatusAccepted:
+			pns.logger.Info(fmt.Sprintf("Server accepted the drain of the node %s, evacuation %s is %s", nodeName, e.ID, e.Status))
+			return
+		case http.StatusNoContent:
+			pns.logger.Info(fmt.Sprintf("Server drained the node %s", nodeName))
+			return
+		}
+		pns.logger.Error(fmt.Sprintf("Trial %d: Error calling Server response status %d", i+1, res.StatusCode))
 	}
 }
 
@@ -0,0 +1,130 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// nodeNameIndex indexes the cached pods by spec.nodeName
+	nodeNameIndex = "nodeName"
+
+	// cacheResyncPeriod is the period after which the informers replay the cached objects to the handlers
+	cacheResyncPeriod = 10 * time.Minute
+)
+
+//INodeEventSource lets the services react to node events as soon as they happen
+//instead of waiting for their next poll.
+type INodeEventSource interface {
+	AddNodeEventHandler(handler cache.ResourceEventHandler)
+}
+
+//CachedClient serves node and pod reads from shared informer caches and delegates every
+//other call, including GetNode which is used right before updates, to the wrapped client.
+type CachedClient struct {
+	IKubernetesClient
+	logger       logger.IZapLogger
+	factory      informers.SharedInformerFactory
+	nodeInformer cache.SharedIndexInformer
+	podInformer  cache.SharedIndexInformer
+	nodeLister   corelisters.NodeLister
+}
+
+func NewCachedClient(kc KubernetesClient, zl logger.IZapLogger) *CachedClient {
+	return newCachedClient(kc, kc.Clientset, zl)
+}
+
+//newCachedClient builds the informers on the given clientset and delegates the calls it does not cache to kc.
+func newCachedClient(kc IKubernetesClient, cs kubernetes.Interface, zl logger.IZapLogger) *CachedClient {
+	factory := informers.NewSharedInformerFactory(cs, cacheResyncPeriod)
+
+	nodes := factory.Core().V1().Nodes()
+	podInformer := factory.Core().V1().Pods().Informer()
+
+	err := podInformer.AddIndexers(cache.Indexers{nodeNameIndex: podNodeNameIndexFunc})
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return &CachedClient{
+		IKubernetesClient: kc,
+		logger:            zl,
+		factory:           factory,
+		nodeInformer:      nodes.Informer(),
+		podInformer:       podInformer,
+		nodeLister:        nodes.Lister(),
+	}
+}
+
+func podNodeNameIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return []string{}, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+//Start starts the informers and blocks until their caches are synced.
+//The informers are stopped when the context is cancelled.
+func (cc *CachedClient) Start(ctx context.Context) error {
+	cc.logger.Info("Starting node and pod informers")
+	cc.factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), cc.nodeInformer.HasSynced, cc.podInformer.HasSynced) {
+		return errors.New("timed out waiting for node and pod caches to sync")
+	}
+	cc.logger.Info("Node and pod caches synced")
+	return nil
+}
+
+//AddNodeEventHandler registers a handler for the node add, update and delete events.
+func (cc *CachedClient) AddNodeEventHandler(handler cache.ResourceEventHandler) {
+	cc.nodeInformer.AddEventHandler(handler)
+}
+
+func (cc *CachedClient) GetNodes(labelSelector string) (*v1.NodeList, error) {
+	cc.logger.Debug(fmt.Sprintf("Label Selectors : %v", labelSelector))
+
+	nodeList := &v1.NodeList{}
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nodeList, err
+	}
+
+	nodes, err := cc.nodeLister.List(selector)
+	if err != nil {
+		cc.logger.Info("Failed to get nodes")
+		return nodeList, err
+	}
+
+	// Objects in the cache are shared and must not be modified, hand out copies.
+	for _, node := range nodes {
+		nodeList.Items = append(nodeList.Items, *node.DeepCopy())
+	}
+	return nodeList, nil
+}
+
+func (cc *CachedClient) GetPodsInNode(name string) ([]v1.Pod, error) {
+	pods := []v1.Pod{}
+	objs, err := cc.podInformer.GetIndexer().ByIndex(nodeNameIndex, name)
+	if err != nil {
+		return pods, err
+	}
+
+	for _, obj := range objs {
+		if pod, ok := obj.(*v1.Pod); ok {
+			pods = append(pods, *pod.DeepCopy())
+		}
+	}
+	return pods, nil
+}
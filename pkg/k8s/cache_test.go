@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type CachedClientTestSuite struct {
+	suite.Suite
+	k8sMock    *K8sClientMock
+	configMock *config.ProviderMock
+	logger     logger.IZapLogger
+	cancel     context.CancelFunc
+}
+
+func (ct *CachedClientTestSuite) SetupTest() {
+	ct.k8sMock = new(K8sClientMock)
+	ct.configMock = new(config.ProviderMock)
+	ct.configMock.On("GetString", mock.Anything).Return("debug")
+	ct.logger = logger.Init(ct.configMock)
+}
+
+func (ct *CachedClientTestSuite) TearDownTest() {
+	if ct.cancel != nil {
+		ct.cancel()
+	}
+}
+
+func (ct *CachedClientTestSuite) startCachedClient(objects ...runtime.Object) *CachedClient {
+	cc := newCachedClient(ct.k8sMock, fake.NewSimpleClientset(objects...), ct.logger)
+
+	var ctx context.Context
+	ctx, ct.cancel = context.WithCancel(context.Background())
+	assert.Nil(ct.T(), cc.Start(ctx))
+	return cc
+}
+
+func (ct *CachedClientTestSuite) TestShouldGetNodesMatchingTheSelector() {
+	preemptible := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1", Labels: map[string]string{"cloud.google.com/gke-preemptible": "true"}}}
+	onDemand := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-2"}}
+
+	cc := ct.startCachedClient(preemptible, onDemand)
+
+	nodes, err := cc.GetNodes("cloud.google.com/gke-preemptible=true")
+	assert.Nil(ct.T(), err)
+	assert.Equal(ct.T(), []v1.Node{*preemptible}, nodes.Items)
+
+	nodes, err = cc.GetNodes("")
+	assert.Nil(ct.T(), err)
+	assert.Equal(ct.T(), 2, len(nodes.Items), "An empty selector should match every node")
+
+	_, err = cc.GetNodes("cloud.google.com/gke-preemptible in (")
+	assert.NotNil(ct.T(), err, "An invalid selector should be reported")
+}
+
+func (ct *CachedClientTestSuite) TestShouldGetPodsScheduledOnTheNode() {
+	pod1 := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1"}, Spec: v1.PodSpec{NodeName: "Node-1"}}
+	pod2 := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2", Namespace: "ns2"}, Spec: v1.PodSpec{NodeName: "Node-1"}}
+	pod3 := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod3", Namespace: "ns1"}, Spec: v1.PodSpec{NodeName: "Node-2"}}
+	pending := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod4", Namespace: "ns1"}}
+
+	cc := ct.startCachedClient(pod1, pod2, pod3, pending)
+
+	pods, err := cc.GetPodsInNode("Node-1")
+	assert.Nil(ct.T(), err)
+	assert.ElementsMatch(ct.T(), []v1.Pod{*pod1, *pod2}, pods)
+
+	pods, err = cc.GetPodsInNode("Node-3")
+	assert.Nil(ct.T(), err)
+	assert.Empty(ct.T(), pods)
+}
+
+func (ct *CachedClientTestSuite) TestShouldNotModifyTheCacheThroughReturnedObjects() {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1", Annotations: map[string]string{}}}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1", Labels: map[string]string{}}, Spec: v1.PodSpec{NodeName: "Node-1"}}
+
+	cc := ct.startCachedClient(node, pod)
+
+	nodes, err := cc.GetNodes("")
+	assert.Nil(ct.T(), err)
+	nodes.Items[0].Annotations[config.StateAnnotation] = "draining"
+	nodes.Items[0].Spec.Unschedulable = true
+
+	pods, err := cc.GetPodsInNode("Node-1")
+	assert.Nil(ct.T(), err)
+	pods[0].Labels["app"] = "web"
+
+	nodes, err = cc.GetNodes("")
+	assert.Nil(ct.T(), err)
+	assert.Equal(ct.T(), []v1.Node{*node}, nodes.Items)
+
+	pods, err = cc.GetPodsInNode("Node-1")
+	assert.Nil(ct.T(), err)
+	assert.Equal(ct.T(), []v1.Pod{*pod}, pods)
+}
+
+func (ct *CachedClientTestSuite) TestShouldDelegateGetNodeToTheWrappedClient() {
+	cached := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1", ResourceVersion: "1"}}
+	latest := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1", ResourceVersion: "2"}}
+	ct.k8sMock.On("GetNode", "Node-1").Return(latest, nil)
+
+	cc := ct.startCachedClient(cached)
+
+	node, err := cc.GetNode("Node-1")
+	assert.Nil(ct.T(), err)
+	assert.Equal(ct.T(), latest, node)
+}
+
+func TestCachedClientTestSuite(t *testing.T) {
+	suite.Run(t, new(CachedClientTestSuite))
+}
@@ -7,7 +7,10 @@ import (
 
 	"github.com/roppenlabs/silent-assassin/pkg/config"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/rest"
@@ -16,16 +19,26 @@ import (
 
 type KubernetesClient struct {
 	*kubernetes.Clientset
-	logger logger.IZapLogger
+	//Dynamic reads and writes the custom resources, which have no typed client.
+	Dynamic dynamic.Interface
+	logger  logger.IZapLogger
 }
 
 type IKubernetesClient interface {
 	GetNodes(labelSelector string) (*v1.NodeList, error)
 	GetNode(name string) (v1.Node, error)
 	GetPodsInNode(name string) ([]v1.Pod, error)
+	WatchPodsInNode(name string) (watch.Interface, error)
+	CreatePod(pod v1.Pod) (v1.Pod, error)
 	DeletePod(name, namespace string) error
+	EvictPod(name, namespace string) error
 	DeleteNode(name string) error
 	UpdateNode(node v1.Node) error
+	GetConfigMap(name, namespace string) (v1.ConfigMap, error)
+	CreateConfigMap(configMap v1.ConfigMap) error
+	UpdateConfigMap(configMap v1.ConfigMap) error
+	GetReplicaSet(name, namespace string) (appsv1.ReplicaSet, error)
+	GetStatefulSet(name, namespace string) (appsv1.StatefulSet, error)
 }
 
 func NewClient(cp config.IProvider, zl logger.IZapLogger) KubernetesClient {
@@ -47,7 +60,12 @@ func NewClient(cp config.IProvider, zl logger.IZapLogger) KubernetesClient {
 		panic(err.Error())
 	}
 
-	return KubernetesClient{logger: zl, Clientset: clientset}
+	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return KubernetesClient{logger: zl, Clientset: clientset, Dynamic: dynamicClient}
 }
 
 func homeDir() string {
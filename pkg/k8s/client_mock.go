@@ -2,7 +2,9 @@ package k8s
 
 import (
 	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 type K8sClientMock struct {
@@ -29,12 +31,52 @@ func (m *K8sClientMock) DeleteNode(name string) error {
 	return args.Error(0)
 }
 
+func (m *K8sClientMock) CreatePod(pod v1.Pod) (v1.Pod, error) {
+	args := m.Called(pod)
+	return args.Get(0).(v1.Pod), args.Error(1)
+}
+
 func (m *K8sClientMock) DeletePod(name, namespace string) error {
 	args := m.Called(name, namespace)
 	return args.Error(0)
 }
 
+func (m *K8sClientMock) EvictPod(name, namespace string) error {
+	args := m.Called(name, namespace)
+	return args.Error(0)
+}
+
 func (m *K8sClientMock) GetPodsInNode(name string) ([]v1.Pod, error) {
 	args := m.Called(name)
 	return args.Get(0).([]v1.Pod), args.Error(1)
 }
+
+func (m *K8sClientMock) WatchPodsInNode(name string) (watch.Interface, error) {
+	args := m.Called(name)
+	return args.Get(0).(watch.Interface), args.Error(1)
+}
+
+func (m *K8sClientMock) GetConfigMap(name, namespace string) (v1.ConfigMap, error) {
+	args := m.Called(name, namespace)
+	return args.Get(0).(v1.ConfigMap), args.Error(1)
+}
+
+func (m *K8sClientMock) CreateConfigMap(configMap v1.ConfigMap) error {
+	args := m.Called(configMap)
+	return args.Error(0)
+}
+
+func (m *K8sClientMock) UpdateConfigMap(configMap v1.ConfigMap) error {
+	args := m.Called(configMap)
+	return args.Error(0)
+}
+
+func (m *K8sClientMock) GetReplicaSet(name, namespace string) (appsv1.ReplicaSet, error) {
+	args := m.Called(name, namespace)
+	return args.Get(0).(appsv1.ReplicaSet), args.Error(1)
+}
+
+func (m *K8sClientMock) GetStatefulSet(name, namespace string) (appsv1.StatefulSet, error) {
+	args := m.Called(name, namespace)
+	return args.Get(0).(appsv1.StatefulSet), args.Error(1)
+}
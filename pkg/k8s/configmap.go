@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (kc KubernetesClient) GetConfigMap(name, namespace string) (v1.ConfigMap, error) {
+	options := metav1.GetOptions{}
+
+	configMap, err := kc.CoreV1().ConfigMaps(namespace).Get(name, options)
+	if err != nil {
+		return v1.ConfigMap{}, err
+	}
+	return *configMap, nil
+}
+
+func (kc KubernetesClient) CreateConfigMap(configMap v1.ConfigMap) error {
+	_, err := kc.CoreV1().ConfigMaps(configMap.Namespace).Create(&configMap)
+	return err
+}
+
+func (kc KubernetesClient) UpdateConfigMap(configMap v1.ConfigMap) error {
+	_, err := kc.CoreV1().ConfigMaps(configMap.Namespace).Update(&configMap)
+	return err
+}
@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	v1 "k8s.io/api/core/v1"
+)
+
+//DryRunClient logs and notifies the mutations instead of sending them to the API server.
+//The intended node updates and deletions are kept in memory and applied to the reads,
+//so the services carry on as if the mutations had happened, without repeating them every poll.
+type DryRunClient struct {
+	IKubernetesClient
+	logger       logger.IZapLogger
+	notifier     notifier.INotifierClient
+	mutex        *sync.RWMutex
+	updatedNodes map[string]v1.Node
+	deletedNodes map[string]bool
+	deletedPods  map[string]bool
+}
+
+func NewDryRunClient(kc IKubernetesClient, zl logger.IZapLogger, nf notifier.INotifierClient) DryRunClient {
+	zl.Info("Dry run: Kubernetes mutations will only be logged and notified")
+	return DryRunClient{
+		IKubernetesClient: kc,
+		logger:            zl,
+		notifier:          nf,
+		mutex:             &sync.RWMutex{},
+		updatedNodes:      make(map[string]v1.Node),
+		deletedNodes:      make(map[string]bool),
+		deletedPods:       make(map[string]bool),
+	}
+}
+
+func (dc DryRunClient) report(event, details string) {
+	dc.logger.Info(fmt.Sprintf("%s %s: %s", config.DryRunMarker, event, details))
+	dc.notifier.Info(fmt.Sprintf("%s %s", config.DryRunMarker, event), details)
+}
+
+func (dc DryRunClient) GetNodes(labelSelector string) (*v1.NodeList, error) {
+	nodeList, err := dc.IKubernetesClient.GetNodes(labelSelector)
+	if err != nil {
+		return nodeList, err
+	}
+
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	nodes := make([]v1.Node, 0, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		if dc.deletedNodes[node.Name] {
+			continue
+		}
+		if updatedNode, ok := dc.updatedNodes[node.Name]; ok {
+			node = updatedNode
+		}
+		nodes = append(nodes, node)
+	}
+	nodeList.Items = nodes
+	return nodeList, nil
+}
+
+func (dc DryRunClient) GetNode(name string) (v1.Node, error) {
+	node, err := dc.IKubernetesClient.GetNode(name)
+	if err != nil {
+		return node, err
+	}
+
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	if dc.deletedNodes[name] {
+		return node, fmt.Errorf("%s node %s was deleted", config.DryRunMarker, name)
+	}
+	if updatedNode, ok := dc.updatedNodes[name]; ok {
+		return updatedNode, nil
+	}
+	return node, nil
+}
+
+func (dc DryRunClient) GetPodsInNode(name string) ([]v1.Pod, error) {
+	pods, err := dc.IKubernetesClient.GetPodsInNode(name)
+	if err != nil {
+		return pods, err
+	}
+
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	remainingPods := []v1.Pod{}
+	for _, pod := range pods {
+		if !dc.deletedPods[fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)] {
+			remainingPods = append(remainingPods, pod)
+		}
+	}
+	return remainingPods, nil
+}
+
+func (dc DryRunClient) UpdateNode(node v1.Node) error {
+	dc.mutex.Lock()
+	dc.updatedNodes[node.Name] = *node.DeepCopy()
+	dc.mutex.Unlock()
+
+	dc.report(config.EventUpdateNode, fmt.Sprintf("Node: %s\nUnschedulable: %t\nAnnotations: %v", node.Name, node.Spec.Unschedulable, node.Annotations))
+	return nil
+}
+
+func (dc DryRunClient) DeleteNode(name string) error {
+	dc.mutex.Lock()
+	dc.deletedNodes[name] = true
+	delete(dc.updatedNodes, name)
+	dc.mutex.Unlock()
+
+	dc.report(config.EventDeleteNode, fmt.Sprintf("Node: %s", name))
+	return nil
+}
+
+func (dc DryRunClient) CreatePod(pod v1.Pod) (v1.Pod, error) {
+	dc.report(config.EventCreatePod, fmt.Sprintf("Pod: %s/%s", pod.Namespace, pod.Name))
+	return pod, nil
+}
+
+func (dc DryRunClient) DeletePod(name, namespace string) error {
+	dc.mutex.Lock()
+	dc.deletedPods[fmt.Sprintf("%s/%s", namespace, name)] = true
+	dc.mutex.Unlock()
+
+	dc.report(config.EventDeletePod, fmt.Sprintf("Pod: %s/%s", namespace, name))
+	return nil
+}
+
+func (dc DryRunClient) EvictPod(name, namespace string) error {
+	dc.mutex.Lock()
+	dc.deletedPods[fmt.Sprintf("%s/%s", namespace, name)] = true
+	dc.mutex.Unlock()
+
+	dc.report(config.EventEvictPod, fmt.Sprintf("Pod: %s/%s", namespace, name))
+	return nil
+}
@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type DryRunTestSuite struct {
+	suite.Suite
+	k8sMock      *K8sClientMock
+	configMock   *config.ProviderMock
+	logger       logger.IZapLogger
+	notifierMock *notifier.NotifierClientMock
+}
+
+func (dt *DryRunTestSuite) SetupTest() {
+	dt.k8sMock = new(K8sClientMock)
+	dt.configMock = new(config.ProviderMock)
+	dt.notifierMock = new(notifier.NotifierClientMock)
+	dt.configMock.On("GetString", mock.Anything).Return("debug")
+	dt.logger = logger.Init(dt.configMock)
+}
+
+func (dt *DryRunTestSuite) TestShouldNotMutateButReflectMutationsInReads() {
+	node1 := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1"}}
+	node2 := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-2"}}
+	pod1 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1"}}
+	pod2 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2", Namespace: "ns1"}}
+
+	dt.k8sMock.On("GetNodes", "").Return(&v1.NodeList{Items: []v1.Node{node1, node2}}, nil)
+	dt.k8sMock.On("GetNode", "Node-1").Return(node1, nil)
+	dt.k8sMock.On("GetPodsInNode", "Node-1").Return([]v1.Pod{pod1, pod2}, nil)
+
+	dc := NewDryRunClient(dt.k8sMock, dt.logger, dt.notifierMock)
+
+	cordoned := *node1.DeepCopy()
+	cordoned.Spec.Unschedulable = true
+	assert.Nil(dt.T(), dc.UpdateNode(cordoned))
+	assert.Nil(dt.T(), dc.EvictPod("pod1", "ns1"))
+	assert.Nil(dt.T(), dc.DeleteNode("Node-2"))
+
+	node, err := dc.GetNode("Node-1")
+	assert.Nil(dt.T(), err)
+	assert.True(dt.T(), node.Spec.Unschedulable, "Node-1 should be seen as cordoned")
+
+	nodes, err := dc.GetNodes("")
+	assert.Nil(dt.T(), err)
+	assert.Equal(dt.T(), []v1.Node{cordoned}, nodes.Items)
+
+	pods, err := dc.GetPodsInNode("Node-1")
+	assert.Nil(dt.T(), err)
+	assert.Equal(dt.T(), []v1.Pod{pod2}, pods)
+
+	dt.k8sMock.AssertNotCalled(dt.T(), "UpdateNode", mock.Anything)
+	dt.k8sMock.AssertNotCalled(dt.T(), "EvictPod", mock.Anything, mock.Anything)
+	dt.k8sMock.AssertNotCalled(dt.T(), "DeleteNode", mock.Anything)
+}
+
+func TestDryRunTestSuite(t *testing.T) {
+	suite.Run(t, new(DryRunTestSuite))
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 func (kc KubernetesClient) GetPodsInNode(name string) ([]v1.Pod, error) {
@@ -22,8 +24,37 @@ func (kc KubernetesClient) GetPodsInNode(name string) ([]v1.Pod, error) {
 
 }
 
+//WatchPodsInNode watches the pods scheduled on the node, across all namespaces.
+func (kc KubernetesClient) WatchPodsInNode(name string) (watch.Interface, error) {
+	options := metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("spec.nodeName=%s", name),
+	}
+	return kc.CoreV1().Pods("").Watch(options)
+}
+
+func (kc KubernetesClient) CreatePod(pod v1.Pod) (v1.Pod, error) {
+	createdPod, err := kc.Clientset.CoreV1().Pods(pod.Namespace).Create(&pod)
+	if err != nil {
+		return pod, err
+	}
+	return *createdPod, nil
+}
+
 func (kc KubernetesClient) DeletePod(name, namespace string) error {
 	options := &metav1.DeleteOptions{}
 	err := kc.Clientset.CoreV1().Pods(namespace).Delete(name, options)
 	return err
 }
+
+//EvictPod evicts the pod through the Eviction subresource, so the API server
+//refuses the eviction with 429 TooManyRequests when it would violate a PodDisruptionBudget.
+func (kc KubernetesClient) EvictPod(name, namespace string) error {
+	eviction := &policy.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		DeleteOptions: &metav1.DeleteOptions{},
+	}
+	return kc.Clientset.CoreV1().Pods(namespace).Evict(eviction)
+}
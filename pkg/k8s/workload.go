@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (kc KubernetesClient) GetReplicaSet(name, namespace string) (appsv1.ReplicaSet, error) {
+	options := metav1.GetOptions{}
+
+	replicaSet, err := kc.AppsV1().ReplicaSets(namespace).Get(name, options)
+	if err != nil {
+		return appsv1.ReplicaSet{}, err
+	}
+	return *replicaSet, nil
+}
+
+func (kc KubernetesClient) GetStatefulSet(name, namespace string) (appsv1.StatefulSet, error) {
+	options := metav1.GetOptions{}
+
+	statefulSet, err := kc.AppsV1().StatefulSets(namespace).Get(name, options)
+	if err != nil {
+		return appsv1.StatefulSet{}, err
+	}
+	return *statefulSet, nil
+}
@@ -0,0 +1,119 @@
+package killer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/state"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+//ErrNodeInFlight is returned when the kill of a node cannot be changed anymore, as its disruption is in flight.
+var ErrNodeInFlight = errors.New("the disruption of the node is in flight")
+
+//ErrOutsideLifetime is returned when a node is rescheduled outside of its safe lifetime.
+var ErrOutsideLifetime = errors.New("the expiry time is outside of the safe lifetime of the node")
+
+//ErrNodeNotManaged is returned when the node does not match the NODE_SELECTORS, so silent-assassin does not kill it.
+var ErrNodeNotManaged = errors.New("the node is not managed by silent-assassin")
+
+//Reschedule sets the expiry time of the node, replacing its postponement. The expiry time has to be in the future
+//and not after the deadline of the node, the drain timeout of its policy before the end of its 24 hours.
+//It returns the deadline of the node.
+func (ks KillerService) Reschedule(name string, expiry time.Time) (time.Time, error) {
+	node, err := ks.getManagedNode(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if state.InFlight(node) {
+		return time.Time{}, ErrNodeInFlight
+	}
+
+	policies, err := ks.Policies()
+	if err != nil {
+		return time.Time{}, err
+	}
+	deadline := NodeDeadline(node, policies.ForAnnotatedNode(node).DrainingTimeoutMs)
+	if !expiry.After(time.Now()) || expiry.After(deadline) {
+		return deadline, fmt.Errorf("%w, it has to be after now and before %s", ErrOutsideLifetime, deadline.Format(time.RFC1123Z))
+	}
+
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[config.ExpiryTimeAnnotation] = expiry.Format(time.RFC1123Z)
+	delete(node.Annotations, config.PostponeKillUntilAnnotation)
+	delete(node.Annotations, config.SkipExpiryAnnotation)
+	state.Annotate(&node, state.Scheduled)
+	return deadline, ks.kubeClient.UpdateNode(node)
+}
+
+//Expedite makes the node expire now and wakes the Killer up, if it runs on this replica, so the node is drained
+//and deleted right away. Otherwise the Killer of the leader picks it up on its next poll.
+//A running pod annotated with silent-assassin/protect still postpones the kill, until the deadline of the node.
+func (ks KillerService) Expedite(name string) error {
+	node, err := ks.getManagedNode(name)
+	if err != nil {
+		return err
+	}
+	if state.InFlight(node) {
+		return ErrNodeInFlight
+	}
+
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[config.ExpiryTimeAnnotation] = time.Now().Format(time.RFC1123Z)
+	delete(node.Annotations, config.PostponeKillUntilAnnotation)
+	delete(node.Annotations, config.SkipExpiryAnnotation)
+	state.Annotate(&node, state.Scheduled)
+	if err := ks.kubeClient.UpdateNode(node); err != nil {
+		return err
+	}
+
+	select {
+	case ks.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+//Exclude cancels the kill of the node: its expiry time is removed and it is annotated with
+//silent-assassin/skip-expiry, so the Spotter does not set a new one.
+func (ks KillerService) Exclude(name string) error {
+	node, err := ks.getManagedNode(name)
+	if err != nil {
+		return err
+	}
+	if state.InFlight(node) {
+		return ErrNodeInFlight
+	}
+
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[config.SkipExpiryAnnotation] = "true"
+	delete(node.Annotations, config.ExpiryTimeAnnotation)
+	delete(node.Annotations, config.PostponeKillUntilAnnotation)
+	delete(node.Annotations, config.StateAnnotation)
+	return ks.kubeClient.UpdateNode(node)
+}
+
+//getManagedNode gets the node and returns ErrNodeNotManaged when it does not match the NODE_SELECTORS.
+func (ks KillerService) getManagedNode(name string) (v1.Node, error) {
+	node, err := ks.kubeClient.GetNode(name)
+	if err != nil {
+		return v1.Node{}, err
+	}
+	selector, err := labels.Parse(ks.cp.GetString(config.NodeSelectors))
+	if err != nil {
+		return v1.Node{}, err
+	}
+	if !selector.Matches(labels.Set(node.Labels)) {
+		return v1.Node{}, fmt.Errorf("%w, it does not match %s", ErrNodeNotManaged, selector.String())
+	}
+	return node, nil
+}
@@ -0,0 +1,118 @@
+package killer
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//adminKiller returns a killer reading the drain timeout of the default policy, one hour, from a configuration file.
+func (k *KillerTestSuite) adminKiller() KillerService {
+	return k.configuredKiller("")
+}
+
+//configuredKiller returns a killer reading the given configuration, after the drain timeout of one hour.
+func (k *KillerTestSuite) configuredKiller(content string) KillerService {
+	file, err := ioutil.TempFile("", "application*.yaml")
+	if err != nil {
+		k.T().Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("KILLER:\n  DRAINING_TIMEOUT_WHEN_NODE_EXPIRED_MS: 3600000\n" + content)
+	file.Close()
+	cp := config.Init(file.Name())
+	return NewKillerService(cp, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(cp, k.logger), nil, nil)
+}
+
+func (k *KillerTestSuite) adminNode(annotations map[string]string) v1.Node {
+	return v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:              "node-1",
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+		Labels:            map[string]string{"cloud.google.com/gke-preemptible": "true"},
+		Annotations:       annotations,
+	}}
+}
+
+func (k *KillerTestSuite) TestShouldRescheduleWithinSafeLifetime() {
+	node := k.adminNode(map[string]string{
+		config.ExpiryTimeAnnotation:        time.Now().Add(time.Hour).Format(time.RFC1123Z),
+		config.PostponeKillUntilAnnotation: time.Now().Add(2 * time.Hour).Format(time.RFC1123Z),
+	})
+	k.k8sMock.On("GetNode", "node-1").Return(node, nil)
+	k.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	ks := k.adminKiller()
+
+	expiry := time.Now().Add(10 * time.Hour).Truncate(time.Second)
+	deadline, err := ks.Reschedule("node-1", expiry)
+	assert.Nil(k.T(), err)
+	assert.True(k.T(), node.CreationTimestamp.Add(23*time.Hour).Equal(deadline))
+
+	updated := k.k8sMock.Calls[len(k.k8sMock.Calls)-1].Arguments.Get(0).(v1.Node)
+	assert.Equal(k.T(), expiry.Format(time.RFC1123Z), updated.Annotations[config.ExpiryTimeAnnotation])
+	assert.Equal(k.T(), "scheduled", updated.Annotations[config.StateAnnotation])
+	assert.NotContains(k.T(), updated.Annotations, config.PostponeKillUntilAnnotation)
+
+	_, err = ks.Reschedule("node-1", time.Now().Add(22*time.Hour))
+	assert.True(k.T(), errors.Is(err, ErrOutsideLifetime))
+	_, err = ks.Reschedule("node-1", time.Now().Add(-time.Minute))
+	assert.True(k.T(), errors.Is(err, ErrOutsideLifetime))
+	k.k8sMock.AssertNumberOfCalls(k.T(), "UpdateNode", 1)
+}
+
+func (k *KillerTestSuite) TestShouldExpediteAndWakeKiller() {
+	node := k.adminNode(map[string]string{config.SkipExpiryAnnotation: "true"})
+	k.k8sMock.On("GetNode", "node-1").Return(node, nil)
+	k.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	ks := k.adminKiller()
+
+	assert.Nil(k.T(), ks.Expedite("node-1"))
+
+	updated := k.k8sMock.Calls[len(k.k8sMock.Calls)-1].Arguments.Get(0).(v1.Node)
+	expiry, err := time.Parse(time.RFC1123Z, updated.Annotations[config.ExpiryTimeAnnotation])
+	assert.Nil(k.T(), err)
+	assert.False(k.T(), expiry.After(time.Now()))
+	assert.NotContains(k.T(), updated.Annotations, config.SkipExpiryAnnotation)
+	assert.Equal(k.T(), 1, len(ks.wake))
+}
+
+func (k *KillerTestSuite) TestShouldExcludeNodeButNotInFlightNode() {
+	node := k.adminNode(map[string]string{
+		config.ExpiryTimeAnnotation: time.Now().Add(time.Hour).Format(time.RFC1123Z),
+		config.StateAnnotation:      "scheduled",
+	})
+	drainingNode := k.adminNode(map[string]string{config.StateAnnotation: "draining"})
+	drainingNode.Name = "node-2"
+	k.k8sMock.On("GetNode", "node-1").Return(node, nil)
+	k.k8sMock.On("GetNode", "node-2").Return(drainingNode, nil)
+	k.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	ks := k.adminKiller()
+
+	assert.Nil(k.T(), ks.Exclude("node-1"))
+	updated := k.k8sMock.Calls[len(k.k8sMock.Calls)-1].Arguments.Get(0).(v1.Node)
+	assert.Equal(k.T(), map[string]string{config.SkipExpiryAnnotation: "true"}, updated.Annotations)
+
+	assert.Equal(k.T(), ErrNodeInFlight, ks.Exclude("node-2"))
+	assert.Equal(k.T(), ErrNodeInFlight, ks.Expedite("node-2"))
+	k.k8sMock.AssertNumberOfCalls(k.T(), "UpdateNode", 1)
+}
+
+func (k *KillerTestSuite) TestShouldRejectNodesNotMatchingTheNodeSelectors() {
+	node := k.adminNode(nil)
+	node.Labels = map[string]string{"cloud.google.com/gke-nodepool": "on-demand"}
+	k.k8sMock.On("GetNode", "node-1").Return(node, nil)
+	ks := k.adminKiller()
+
+	_, err := ks.Reschedule("node-1", time.Now().Add(time.Hour))
+	assert.True(k.T(), errors.Is(err, ErrNodeNotManaged))
+	assert.True(k.T(), errors.Is(ks.Expedite("node-1"), ErrNodeNotManaged))
+	assert.True(k.T(), errors.Is(ks.Exclude("node-1"), ErrNodeNotManaged))
+	k.k8sMock.AssertNotCalled(k.T(), "UpdateNode", mock.Anything)
+}
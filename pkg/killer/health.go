@@ -0,0 +1,138 @@
+package killer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+//healthGatePollInterval is the interval at which the workloads of the evicted pods are checked for readiness.
+var healthGatePollInterval = 10 * time.Second
+
+//workload is a controller owning evicted pods, whose replacements the health gate waits for.
+type workload struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func (w workload) String() string {
+	return fmt.Sprintf("%s %s/%s", w.kind, w.namespace, w.name)
+}
+
+//getWorkloads returns the ReplicaSets and StatefulSets owning the pods, once each.
+func getWorkloads(pods []v1.Pod) []workload {
+	seen := make(map[workload]bool)
+	workloads := []workload{}
+	for _, pod := range pods {
+		for _, ownerReference := range pod.ObjectMeta.OwnerReferences {
+			if ownerReference.Kind != "ReplicaSet" && ownerReference.Kind != "StatefulSet" {
+				continue
+			}
+			w := workload{kind: ownerReference.Kind, namespace: pod.Namespace, name: ownerReference.Name}
+			if !seen[w] {
+				seen[w] = true
+				workloads = append(workloads, w)
+			}
+		}
+	}
+	sort.Slice(workloads, func(i, j int) bool { return workloads[i].String() < workloads[j].String() })
+	return workloads
+}
+
+//isWorkloadReady returns true if the ready replicas of the workload are back at its desired replicas.
+//A workload which got deleted in the meantime has nothing to wait for.
+func (ks KillerService) isWorkloadReady(w workload) (bool, error) {
+	var replicas *int32
+	var readyReplicas int32
+
+	switch w.kind {
+	case "ReplicaSet":
+		replicaSet, err := ks.kubeClient.GetReplicaSet(w.name, w.namespace)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		replicas, readyReplicas = replicaSet.Spec.Replicas, replicaSet.Status.ReadyReplicas
+	case "StatefulSet":
+		statefulSet, err := ks.kubeClient.GetStatefulSet(w.name, w.namespace)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		replicas, readyReplicas = statefulSet.Spec.Replicas, statefulSet.Status.ReadyReplicas
+	}
+
+	// The desired replicas default to 1 when not set.
+	desired := int32(1)
+	if replicas != nil {
+		desired = *replicas
+	}
+	return readyReplicas >= desired, nil
+}
+
+//waitForWorkloadsReady is the health gate after a drain. It waits until the workloads of the evicted pods
+//are Ready again or the health gate timeout passes, and reports the outcome. A timeout of 0 disables it.
+//It stops waiting, without reporting, once the context is done.
+func (ks KillerService) waitForWorkloadsReady(ctx context.Context, node v1.Node, workloads []workload) error {
+	timeout := time.Duration(ks.cp.GetUint32(config.KillerHealthGateTimeoutMs)) * time.Millisecond
+	if timeout == 0 || len(workloads) == 0 {
+		return nil
+	}
+
+	ks.logger.Info(fmt.Sprintf("Waiting for %d workload(s) evicted from node %s to be Ready", len(workloads), node.Name))
+	start := time.Now()
+
+	gateCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var notReady []workload
+	err := wait.PollImmediateUntil(healthGatePollInterval, func() (bool, error) {
+		notReady = []workload{}
+		for _, w := range workloads {
+			ready, err := ks.isWorkloadReady(w)
+			if err != nil {
+				ks.logger.Warn(fmt.Sprintf("Error getting %s, %s", w, err.Error()))
+			}
+			if !ready {
+				notReady = append(notReady, w)
+			}
+		}
+		return len(notReady) == 0, nil
+	}, gateCtx.Done())
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	nodeDetails := getNodeDetails(node, false)
+	if err != nil {
+		err = fmt.Errorf("workloads not Ready after %v: %s", timeout, workloadNames(notReady))
+		ks.logger.Error(fmt.Sprintf("Health gate failed for node %s, %s", node.Name, err.Error()))
+		ks.notifier.Error(config.EventHealthGate, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
+		return err
+	}
+
+	ks.logger.Info(fmt.Sprintf("Workloads evicted from node %s are Ready after %f seconds", node.Name, time.Since(start).Seconds()))
+	ks.notifier.Info(config.EventHealthGate, fmt.Sprintf("%s\nWorkloads:%s", nodeDetails, workloadNames(workloads)))
+	return nil
+}
+
+//workloadNames returns the workloads as a comma separated string.
+func workloadNames(workloads []workload) string {
+	names := make([]string, 0, len(workloads))
+	for _, w := range workloads {
+		names = append(names, w.String())
+	}
+	return strings.Join(names, config.CommaSeparater)
+}
@@ -0,0 +1,86 @@
+package killer
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func ownedPod(name, namespace, kind, owner string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{{Kind: kind, Name: owner}}}}
+}
+
+func replicaSet(replicas, readyReplicas int32) appsv1.ReplicaSet {
+	return appsv1.ReplicaSet{
+		Spec:   appsv1.ReplicaSetSpec{Replicas: &replicas},
+		Status: appsv1.ReplicaSetStatus{ReadyReplicas: readyReplicas}}
+}
+
+func (k *KillerTestSuite) healthGateConfig(timeoutMs uint32) {
+	healthGatePollInterval = time.Millisecond
+	k.configMock.On("GetUint32", config.KillerHealthGateTimeoutMs).Return(timeoutMs)
+}
+
+func (k *KillerTestSuite) TestShouldGetWorkloadsOfPods() {
+	pods := []v1.Pod{
+		ownedPod("web-1", "ns", "ReplicaSet", "web"),
+		ownedPod("web-2", "ns", "ReplicaSet", "web"),
+		ownedPod("db-0", "ns", "StatefulSet", "db"),
+		ownedPod("job-1", "ns", "Job", "job"),
+	}
+
+	assert.Equal(k.T(), []workload{{kind: "ReplicaSet", namespace: "ns", name: "web"}, {kind: "StatefulSet", namespace: "ns", name: "db"}}, getWorkloads(pods))
+}
+
+func (k *KillerTestSuite) TestShouldWaitForWorkloadsToBeReady() {
+	k.healthGateConfig(1000)
+	replicas := int32(3)
+	k.k8sMock.On("GetReplicaSet", "web", "ns").Return(replicaSet(2, 1), nil).Once()
+	k.k8sMock.On("GetReplicaSet", "web", "ns").Return(replicaSet(2, 2), nil)
+	k.k8sMock.On("GetStatefulSet", "db", "ns").Return(appsv1.StatefulSet{
+		Spec:   appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: 3}}, nil)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	workloads := []workload{{kind: "ReplicaSet", namespace: "ns", name: "web"}, {kind: "StatefulSet", namespace: "ns", name: "db"}}
+	assert.NoError(k.T(), ks.waitForWorkloadsReady(context.Background(), v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1"}}, workloads))
+	k.k8sMock.AssertNumberOfCalls(k.T(), "GetReplicaSet", 2)
+}
+
+func (k *KillerTestSuite) TestShouldTreatDeletedWorkloadAsReady() {
+	k.healthGateConfig(1000)
+	k.k8sMock.On("GetReplicaSet", "web", "ns").Return(appsv1.ReplicaSet{}, apierrors.NewNotFound(schema.GroupResource{Resource: "replicasets"}, "web"))
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.NoError(k.T(), ks.waitForWorkloadsReady(context.Background(), v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1"}}, []workload{{kind: "ReplicaSet", namespace: "ns", name: "web"}}))
+}
+
+func (k *KillerTestSuite) TestShouldTimeoutWhenWorkloadsAreNotReady() {
+	k.healthGateConfig(20)
+	k.k8sMock.On("GetReplicaSet", "web", "ns").Return(replicaSet(2, 1), nil)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	err := ks.waitForWorkloadsReady(context.Background(), v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1"}}, []workload{{kind: "ReplicaSet", namespace: "ns", name: "web"}})
+	assert.EqualError(k.T(), err, "workloads not Ready after 20ms: ReplicaSet ns/web")
+}
+
+func (k *KillerTestSuite) TestShouldSkipHealthGateWhenDisabled() {
+	k.healthGateConfig(0)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.NoError(k.T(), ks.waitForWorkloadsReady(context.Background(), v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1"}}, []workload{{kind: "ReplicaSet", namespace: "ns", name: "web"}}))
+	k.k8sMock.AssertNotCalled(k.T(), "GetReplicaSet", mock.Anything, mock.Anything)
+}
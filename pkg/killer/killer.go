@@ -8,11 +8,16 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/roppenlabs/silent-assassin/pkg/calendar"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
 	"github.com/roppenlabs/silent-assassin/pkg/gcloud"
 	"github.com/roppenlabs/silent-assassin/pkg/k8s"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
 	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/pause"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
+	"github.com/roppenlabs/silent-assassin/pkg/state"
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -21,6 +26,10 @@ var (
 		Name: "nodes_killed",
 		Help: "The total number of nodes killed when they reach their expiry time",
 	}, []string{"nodePool"})
+	orphanedInstances = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orphaned_instances",
+		Help: "The total number of instances which still exist after their node got deleted",
+	}, []string{"nodePool"})
 )
 
 type IKiller interface {
@@ -33,15 +42,25 @@ type KillerService struct {
 	kubeClient   k8s.IKubernetesClient
 	gcloudClient gcloud.IGCloudClient
 	notifier     notifier.INotifierClient
+	coordinator  disruption.ICoordinator
+	policySource policy.ISource
+	pauser       pause.IPauser
+	wake         chan struct{}
+	surges       *nodePoolLocks
 }
 
-func NewKillerService(cp config.IProvider, zl logger.IZapLogger, kc k8s.IKubernetesClient, gc gcloud.IGCloudClient, nf notifier.INotifierClient) KillerService {
+func NewKillerService(cp config.IProvider, zl logger.IZapLogger, kc k8s.IKubernetesClient, gc gcloud.IGCloudClient, nf notifier.INotifierClient, dc disruption.ICoordinator, ps policy.ISource, pa pause.IPauser) KillerService {
 	return KillerService{
 		cp:           cp,
 		logger:       zl,
 		kubeClient:   kc,
 		gcloudClient: gc,
 		notifier:     nf,
+		coordinator:  dc,
+		policySource: ps,
+		pauser:       pa,
+		wake:         make(chan struct{}, 1),
+		surges:       newNodePoolLocks(),
 	}
 }
 
@@ -49,47 +68,154 @@ func (ks KillerService) Start(ctx context.Context, wg *sync.WaitGroup) {
 	ks.logger.Info(fmt.Sprintf("Starting Killer Loop - Poll Interval : %d", ks.cp.GetInt(config.KillerPollIntervalMs)))
 
 	for {
+		ks.kill(ctx)
 		select {
 		case <-ctx.Done():
 			ks.logger.Info("Shutting down killer service")
 			wg.Done()
 			return
-		default:
-			ks.kill()
-			time.Sleep(time.Millisecond * time.Duration(ks.cp.GetInt(config.KillerPollIntervalMs)))
+		case <-ks.wake:
+			ks.logger.Info("Killer woken up by an expedited node")
+		case <-time.After(time.Millisecond * time.Duration(ks.cp.GetInt(config.KillerPollIntervalMs))):
 		}
 	}
 }
 
-func (ks KillerService) kill() {
+//kill kills the expired nodes. Once the context is done, when the lease is lost, no new node is started,
+//the nodes in flight are left in their state for the next leader to carry on.
+func (ks KillerService) kill(ctx context.Context) {
 
-	nodesToDelete, err := ks.findExpiredTimeNodes(ks.cp.GetString(config.NodeSelectors))
+	policies, err := policy.Load(ks.cp, ks.policySource)
+	if err != nil {
+		ks.logger.Error(fmt.Sprintf("Error loading policies %s", err.Error()))
+		ks.notifier.Error(config.EventGetNodes, fmt.Sprintf("Error loading policies %s", err.Error()))
+		return
+	}
 
+	blackout, err := calendar.LoadBlackout(ks.cp, ks.kubeClient)
 	if err != nil {
+		ks.logger.Error(fmt.Sprintf("Error loading blackout dates %s", err.Error()))
+		ks.notifier.Error(config.EventBlackout, fmt.Sprintf("Error loading blackout dates %s", err.Error()))
+		return
+	}
+
+	nodesToDelete, err := ks.findExpiredTimeNodes(ks.cp.GetString(config.NodeSelectors), policies, blackout)
+
+	if err != nil {
+		return
+	}
+
+	// While the disruptions are paused the expired nodes are only reported, they are killed once resumed.
+	if ks.pauser != nil && ks.pauser.Paused("Killer") {
+		if len(nodesToDelete) > 0 {
+			names := make([]string, len(nodesToDelete))
+			for i, node := range nodesToDelete {
+				names[i] = node.Name
+			}
+			ks.pauser.Defer("Killer", "the kill of", names...)
+		}
 		return
 	}
 
 	ks.logger.Debug(fmt.Sprintf("Number of nodes to kill %d", len(nodesToDelete)))
 	startTime := time.Now()
+
+	// Nodes are killed concurrently up to the concurrency of their policy.
+	wg := &sync.WaitGroup{}
+	semaphores := make(map[string]chan struct{})
 	for _, node := range nodesToDelete {
-		ks.logger.Info(fmt.Sprintf("Processing node %s", node.Name))
+		nodePolicy := policies.ForAnnotatedNode(node)
+		semaphore, ok := semaphores[nodePolicy.Name]
+		if !ok {
+			semaphore = make(chan struct{}, nodePolicy.Concurrency)
+			semaphores[nodePolicy.Name] = semaphore
+		}
+
+		wg.Add(1)
+		go func(node v1.Node, nodePolicy policy.Policy) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			if ctx.Err() != nil {
+				ks.logger.Info(fmt.Sprintf("Killer stopped, not killing node %s", node.Name))
+				return
+			}
+			// A pause set while the node waited for its turn defers it until the disruptions are resumed.
+			if ks.pauser != nil && ks.pauser.Paused("Killer") {
+				ks.pauser.Defer("Killer", "the kill of", node.Name)
+				return
+			}
+			ks.killNode(ctx, node, nodePolicy)
+		}(node, nodePolicy)
+	}
+	wg.Wait()
+	timeDuration := time.Now().Sub(startTime).Seconds()
 
+	if len(nodesToDelete) > 0 {
+		ks.logger.Info(fmt.Sprintf("Kill time duration in seconds : %f, number of nodes : %d", timeDuration, len(nodesToDelete)))
+	}
+}
+
+//killNode surges a replacement node when enabled, drains the node within the drain timeout of its policy,
+//waits for the evicted workloads to be Ready and deletes the node. It stops between these steps once the context
+//is done, the state of the node tells the next leader where to carry on.
+func (ks KillerService) killNode(ctx context.Context, node v1.Node, nodePolicy policy.Policy) {
+	ks.logger.Info(fmt.Sprintf("Processing node %s with policy %s in state %s", node.Name, nodePolicy.Name, state.Of(node)))
+	// Every notification about the node goes to the channel of its policy.
+	ks.notifier = ks.notifier.Route(nodePolicy.SlackChannel)
+
+	// A drained node, found after a restart, only has its deletion left.
+	switch state.Of(node) {
+	case state.Drained, state.InstanceDeleted:
+		ks.deleteNode(node)
+		return
+	case state.Draining:
+		// The surge already happened before the drain started.
+	default:
 		nodePool := node.Labels[ks.cp.GetString(config.NodePoolLabel)]
 		nodesKilled.WithLabelValues(nodePool).Inc()
 
-		if err := ks.EvacuatePodsFromNode(node.Name, ks.cp.GetUint32(config.KillerDrainingTimeoutWhenNodeExpiredMs), false); err != nil {
-			ks.logger.Error(fmt.Sprintf("Error evacuating node:%s, %s", node.Name, err.Error()))
-			continue
+		// The node is drained even if the surge fails, as it is going to be preempted soon anyway.
+		if err := ks.surge(ctx, node); err != nil {
+			ks.logger.Error(fmt.Sprintf("Error surging a node for node %s, %s", node.Name, err.Error()))
+			ks.notifier.Error(config.EventSurge, fmt.Sprintf("%s\nError:%s", getNodeDetails(node, false), err.Error()))
 		}
+	}
 
-		ks.deleteNode(node)
+	if ctx.Err() != nil {
+		ks.logger.Info(fmt.Sprintf("Killer stopped before draining node %s", node.Name))
+		return
+	}
 
+	// The owners of the pods are recorded before the drain, as the pods are gone afterwards.
+	pods, err := ks.getPodsToBeDeleted(node.Name)
+	if err != nil {
+		ks.logger.Warn(fmt.Sprintf("Error fetching the pods of node %s for the health gate, %s", node.Name, err.Error()))
 	}
-	timeDuration := time.Now().Sub(startTime).Seconds()
 
-	if len(nodesToDelete) > 0 {
-		ks.logger.Info(fmt.Sprintf("Kill time duration in seconds : %f, number of nodes : %d", timeDuration, len(nodesToDelete)))
+	if err := ks.EvacuatePodsFromNode(node.Name, nodePolicy.DrainingTimeoutMs, false); err != nil {
+		ks.logger.Error(fmt.Sprintf("Error evacuating node:%s, %s", node.Name, err.Error()))
+		if err := ks.rollbackFailedDrain(node, nodePolicy, err); err != nil {
+			ks.logger.Error(fmt.Sprintf("Error rolling back the drain of node %s, %s", node.Name, err.Error()))
+			ks.notifier.Error(config.EventRollback, fmt.Sprintf("%s\nError:%s", getNodeDetails(node, false), err.Error()))
+		}
+		return
+	}
+
+	// The killer moves on to the next node only once the evicted workloads are Ready again, or the gate times out.
+	// The node is deleted either way, as it has no pods left.
+	ks.waitForWorkloadsReady(ctx, node, getWorkloads(pods))
+	if ctx.Err() != nil {
+		ks.logger.Info(fmt.Sprintf("Killer stopped before deleting drained node %s", node.Name))
+		return
 	}
+
+	ks.deleteNode(node)
+}
+
+//Policies loads the policies the Killer applies to the nodes.
+func (ks KillerService) Policies() (policy.Policies, error) {
+	return policy.Load(ks.cp, ks.policySource)
 }
 
 func (ks KillerService) GetNode(name string) (v1.Node, error) {
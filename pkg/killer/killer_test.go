@@ -1,14 +1,18 @@
 package killer
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/roppenlabs/silent-assassin/pkg/calendar"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
 	"github.com/roppenlabs/silent-assassin/pkg/gcloud"
 	"github.com/roppenlabs/silent-assassin/pkg/k8s"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
 	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -16,6 +20,20 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+//pauserStub is paused when paused is true and records the deferred items.
+type pauserStub struct {
+	paused   bool
+	deferred []string
+}
+
+func (p *pauserStub) Paused(component string) bool {
+	return p.paused
+}
+
+func (p *pauserStub) Defer(component, action string, items ...string) {
+	p.deferred = append(p.deferred, items...)
+}
+
 type KillerTestSuite struct {
 	suite.Suite
 	k8sMock      *k8s.K8sClientMock
@@ -33,6 +51,11 @@ func (k *KillerTestSuite) SetupTest() {
 	k.notifierMock.On("Info", mock.Anything, mock.Anything)
 	k.notifierMock.On("Error", mock.Anything, mock.Anything)
 	k.configMock.On("GetString", mock.Anything).Return("debug")
+	k.configMock.On("GetInt", config.KillerEvictionRetries).Return(2)
+	k.configMock.On("GetInt", config.KillerEvictionBackoffMs).Return(10)
+	k.configMock.On("GetUint32", config.KillerDrainingTimeoutWhenNodeExpiredMs).Return(uint32(300000))
+	k.configMock.On("GetInt", config.DisruptionMaxConcurrentDrains).Return(0)
+	k.configMock.On("GetInt", config.DisruptionMaxConcurrentDrainsPerNodePool).Return(0)
 	k.configMock.On("GetStringSlice", config.NodeSelectors).Return([]string{"cloud.google.com/gke-preemptible=true,label2=test"})
 	k.logger = logger.Init(k.configMock)
 }
@@ -50,15 +73,89 @@ func (k *KillerTestSuite) TestShouldReturnExpiredNodes() {
 
 	k.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true,label2=test").Return(&nodeList, nil)
 
-	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
 
-	nodelist, _ := ks.findExpiredTimeNodes("cloud.google.com/gke-preemptible=true,label2=test")
+	nodelist, _ := ks.findExpiredTimeNodes("cloud.google.com/gke-preemptible=true,label2=test", policy.Policies{}, nil)
 
 	assert.Contains(k.T(), nodelist, preemptibleNodeExpired, "Node-1 should be returned")
 	assert.NotContains(k.T(), nodelist, preemptibleNodeNotExpired, "Node-2 should not be returned")
 	k.k8sMock.AssertExpectations(k.T())
 }
 
+func (k *KillerTestSuite) TestShouldDeferExpiredNodesWhilePaused() {
+	expired := k.adminNode(map[string]string{config.ExpiryTimeAnnotation: time.Now().Add(-time.Minute).Format(time.RFC1123Z)})
+	k.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true").Return(&v1.NodeList{Items: []v1.Node{expired}}, nil)
+	k.k8sMock.On("GetPodsInNode", "node-1").Return([]v1.Pod{}, nil)
+	pauser := &pauserStub{paused: true}
+	ks := k.adminKiller()
+	ks.pauser = pauser
+
+	ks.kill(context.Background())
+	assert.Equal(k.T(), []string{"node-1"}, pauser.deferred)
+	k.k8sMock.AssertNotCalled(k.T(), "UpdateNode", mock.Anything)
+	k.k8sMock.AssertNotCalled(k.T(), "EvictPod", mock.Anything, mock.Anything)
+}
+
+func (k *KillerTestSuite) TestShouldDeferNodesLeftWhenPausedBetweenTwoNodesOfAPolicy() {
+	nodes := []v1.Node{}
+	for _, name := range []string{"node-1", "node-2"} {
+		node := k.adminNode(map[string]string{
+			config.ExpiryTimeAnnotation: time.Now().Add(-time.Minute).Format(time.RFC1123Z),
+			config.StateAnnotation:      "instance-deleted",
+		})
+		node.Name = name
+		node.Spec.ProviderID = "gce://project-1/asia-south1-a/" + name
+		nodes = append(nodes, node)
+	}
+	k.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true").Return(&v1.NodeList{Items: nodes}, nil)
+	k.gCloudMock.On("RecreateInstance", "asia-south1-a", mock.Anything).Return(nil)
+	pauser := &pauserStub{}
+	// The default policy kills one node at a time, the pause starts once the first node is deleted.
+	k.k8sMock.On("DeleteNode", mock.Anything).Return(nil).Run(func(args mock.Arguments) { pauser.paused = true })
+	ks := k.adminKiller()
+	ks.pauser = pauser
+
+	ks.kill(context.Background())
+	k.k8sMock.AssertNumberOfCalls(k.T(), "DeleteNode", 1)
+	k.gCloudMock.AssertNumberOfCalls(k.T(), "RecreateInstance", 1)
+	assert.Equal(k.T(), 1, len(pauser.deferred))
+	k.k8sMock.AssertNotCalled(k.T(), "DeleteNode", pauser.deferred[0])
+}
+
+func (k *KillerTestSuite) TestShouldNotStartKillingNodesOnceTheLeaseIsLost() {
+	expired := k.adminNode(map[string]string{
+		config.ExpiryTimeAnnotation: time.Now().Add(-time.Minute).Format(time.RFC1123Z),
+		config.StateAnnotation:      "drained",
+	})
+	k.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true").Return(&v1.NodeList{Items: []v1.Node{expired}}, nil)
+	ks := k.adminKiller()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ks.kill(ctx)
+	k.k8sMock.AssertNotCalled(k.T(), "DeleteNode", mock.Anything)
+	k.gCloudMock.AssertNotCalled(k.T(), "RecreateInstance", mock.Anything, mock.Anything)
+}
+
+func (k *KillerTestSuite) TestShouldNotKillExpiredNodesOnBlackoutDateUntilTheirDeadline() {
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	today := time.Now().In(kolkata).Format("2006-01-02")
+	expired := k.adminNode(map[string]string{config.ExpiryTimeAnnotation: time.Now().Add(-time.Minute).Format(time.RFC1123Z)})
+	k.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true").Return(&v1.NodeList{Items: []v1.Node{expired}}, nil)
+	ks := k.configuredKiller("SPOTTER:\n  WHITE_LIST_TIMEZONE: Asia/Kolkata\nBLACKOUT:\n  DATES: ['" + today + "']\n")
+
+	ks.kill(context.Background())
+	k.k8sMock.AssertNotCalled(k.T(), "GetPodsInNode", mock.Anything)
+	k.k8sMock.AssertNotCalled(k.T(), "UpdateNode", mock.Anything)
+
+	// The blackout holds the kill until the deadline of the node, its drain timeout before the end of its lifetime.
+	blackout := calendar.Blackout{time.Now().UTC().Format("2006-01-02"): true}
+	deadline := NodeDeadline(expired, 3600000)
+	nodePolicy := policy.Policy{DrainingTimeoutMs: 3600000}
+	assert.True(k.T(), ks.isKillPostponed(expired, time.Now(), nodePolicy, blackout))
+	assert.False(k.T(), ks.isKillPostponed(expired, deadline, nodePolicy, blackout))
+}
+
 func (k *KillerTestSuite) TestShouldFilterPodsByReferenceKind() {
 	podOwnedByDS := v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1,14 +1,28 @@
 package killer
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/roppenlabs/silent-assassin/pkg/calendar"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
+	"github.com/roppenlabs/silent-assassin/pkg/state"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
+//drainResyncInterval is the interval at which the pods pending deletion are checked against the pods on the node.
+const drainResyncInterval = 5 * time.Second
+
+//migActionRecreate replaces the instances of killed nodes through their managed instance group, instead of shrinking it.
+const migActionRecreate = "recreate"
+
 //getExpiryTime returns the expiry time set on the node
 //using the annotation silent-assassin/expiry-time
 func getExpiryTime(node v1.Node) string {
@@ -21,8 +35,9 @@ func getExpiryTime(node v1.Node) string {
 }
 
 //findExpiredTimeNodes gets the list of nodes whose expiry time set is older than current time
-//These nodes are eligible for deletion.
-func (ks KillerService) findExpiredTimeNodes(labelSelector string) ([]v1.Node, error) {
+//These nodes are eligible for deletion. Nodes whose disruption is in flight, after a restart of the
+//Killer for example, are returned whatever their expiry time to carry it on, and failed nodes are never returned.
+func (ks KillerService) findExpiredTimeNodes(labelSelector string, policies policy.Policies, blackout calendar.Blackout) ([]v1.Node, error) {
 	var nodesToBeDeleted []v1.Node
 	nodeList, err := ks.kubeClient.GetNodes(labelSelector)
 	if err != nil {
@@ -34,6 +49,15 @@ func (ks KillerService) findExpiredTimeNodes(labelSelector string) ([]v1.Node, e
 	now := time.Now().UTC()
 
 	for _, node := range nodeList.Items {
+		if state.InFlight(node) {
+			nodesToBeDeleted = append(nodesToBeDeleted, node)
+			continue
+		}
+		// A node which failed to drain at its deadline was escalated once, it is left to be preempted.
+		if state.Of(node) == state.Failed {
+			ks.logger.Debug(fmt.Sprintf("Node %s failed to drain before its deadline, skipping it", node.Name))
+			continue
+		}
 		timestamp := getExpiryTime(node)
 		if timestamp == "" {
 			ks.logger.Warn(fmt.Sprintf("Node %s does not have %s annotation set", node.Name, config.ExpiryTimeAnnotation))
@@ -47,7 +71,7 @@ func (ks KillerService) findExpiredTimeNodes(labelSelector string) ([]v1.Node, e
 
 		timeDiff := expiryDatetime.Sub(now).Minutes()
 
-		if timeDiff <= 0 {
+		if timeDiff <= 0 && !ks.isKillPostponed(node, now, policies.ForAnnotatedNode(node), blackout) {
 			nodesToBeDeleted = append(nodesToBeDeleted, node)
 		}
 
@@ -56,6 +80,52 @@ func (ks KillerService) findExpiredTimeNodes(labelSelector string) ([]v1.Node, e
 	return nodesToBeDeleted, nil
 }
 
+//NodeDeadline returns the latest time at which the drain of the node can start and still
+//finish within the drain timeout, before the node reaches its 24 hours lifetime.
+func NodeDeadline(node v1.Node, drainingTimeoutMs uint32) time.Time {
+	return node.CreationTimestamp.Add(config.NodeLifetime - time.Millisecond*time.Duration(drainingTimeoutMs))
+}
+
+//isKillPostponed returns true if the kill of the expired node is postponed, either by the node's
+//silent-assassin/postpone-kill-until annotation, by a running pod annotated with silent-assassin/protect
+//or because the day is a blackout date in the timezone of the node's policy.
+//A kill is postponed at most until the node's deadline, the drain timeout of its policy before the end of its
+//24 hours lifetime, so it is still drained before it gets preempted.
+func (ks KillerService) isKillPostponed(node v1.Node, now time.Time, nodePolicy policy.Policy, blackout calendar.Blackout) bool {
+	deadline := NodeDeadline(node, nodePolicy.DrainingTimeoutMs)
+	if !now.Before(deadline) {
+		return false
+	}
+
+	if day := now.In(nodePolicy.Location()); blackout.Contains(day) {
+		ks.logger.Info(fmt.Sprintf("Kill of node %s postponed as %s is a blackout date, until %s at the latest", node.Name, day.Format("2006-01-02"), deadline.Format(time.RFC1123Z)))
+		return true
+	}
+
+	if timestamp, ok := node.Annotations[config.PostponeKillUntilAnnotation]; ok {
+		postponeUntil, err := time.Parse(time.RFC1123Z, timestamp)
+		if err != nil {
+			ks.logger.Error(fmt.Sprintf("Error parsing %s of node %s with value '%s', %s", config.PostponeKillUntilAnnotation, node.Name, timestamp, err.Error()))
+		} else if now.Before(postponeUntil) {
+			ks.logger.Info(fmt.Sprintf("Kill of node %s postponed until %s", node.Name, postponeUntil.Format(time.RFC1123Z)))
+			return true
+		}
+	}
+
+	pods, err := ks.kubeClient.GetPodsInNode(node.Name)
+	if err != nil {
+		ks.logger.Error(fmt.Sprintf("Error fetching the pods of node %s, %s", node.Name, err.Error()))
+		return false
+	}
+	for _, pod := range pods {
+		if pod.Annotations[config.ProtectPodAnnotation] == "true" && pod.Status.Phase == v1.PodRunning {
+			ks.logger.Info(fmt.Sprintf("Kill of node %s postponed while pod %s is running, until %s at the latest", node.Name, getPodKey(pod), deadline.Format(time.RFC1123Z)))
+			return true
+		}
+	}
+	return false
+}
+
 //makeNodeUnschedulable function cordons the node thus disabling scheduling of
 //any new pods on this node during draining.
 func (ks KillerService) makeNodeUnschedulable(node v1.Node) error {
@@ -66,48 +136,173 @@ func (ks KillerService) makeNodeUnschedulable(node v1.Node) error {
 
 }
 
-//waitforDrainToFinish function waits for the pods that were deleted by startDrainNode method
-//to get evicted from the node. This takes  timeout as an argument, if the draining of nodes
-//takes more time than the specified timeout, then the function returns timeout error.
-func (ks KillerService) waitforDrainToFinish(nodeName string, timeout uint32) error {
-	start := time.Now()
+//waitforDrainToFinish function waits for the pods that were evicted by startNodeDrain method
+//to get deleted from the node. Deletions are tracked with a watch on the pods of the node instead
+//of polling. If the context of the drain is done, at the drain timeout, the watch is cancelled and a
+//timeout error listing the pods that are still terminating is returned.
+func (ks KillerService) waitforDrainToFinish(ctx context.Context, nodeName string, timeout uint32) error {
 	for {
+		// The watch is started before listing the pods, so no deletion is missed in between.
+		watcher, err := ks.kubeClient.WatchPodsInNode(nodeName)
+		if err != nil {
+			ks.logger.Error(fmt.Sprintf("Error watching pods: %s", err.Error()))
+			return err
+		}
+
 		podsPending, err := ks.getPodsToBeDeleted(nodeName)
 		if err != nil {
+			watcher.Stop()
 			ks.logger.Error(fmt.Sprintf("Error fetching pods: %s", err.Error()))
 			return err
 		}
 
-		if len(podsPending) == 0 {
+		pending := make(map[string]v1.Pod, len(podsPending))
+		for _, pod := range podsPending {
+			pending[getPodKey(pod)] = pod
+		}
+
+		done, err := ks.watchPodDeletions(ctx, nodeName, watcher, pending)
+		watcher.Stop()
+		if err != nil {
+			return fmt.Errorf("Drainout timed out. Drain duration exceeded %d mill seconds, pods still terminating: %s", timeout, getPodNames(podsFromMap(pending)))
+		}
+		if done {
 			return nil
 		}
-		elapsed := uint32(time.Since(start).Milliseconds())
-		if elapsed >= timeout {
-			return fmt.Errorf("Drainout timed out. Drain duration exceeded %d mill seconds", timeout)
+		// The API server closed the watch, start over with a fresh list.
+		ks.logger.Debug(fmt.Sprintf("Watch on pods of node %s closed, restarting it", nodeName))
+	}
+}
+
+//watchPodDeletions removes the deleted pods from pending until it is empty. It returns false
+//if the watch was closed before that, and the context error if the context got cancelled.
+//As the pods may be listed from a cache that lags behind the watch, pending is periodically
+//resynced with the pods still present on the node.
+func (ks KillerService) watchPodDeletions(ctx context.Context, nodeName string, watcher watch.Interface, pending map[string]v1.Pod) (bool, error) {
+	ticker := time.NewTicker(drainResyncInterval)
+	defer ticker.Stop()
+
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-ticker.C:
+			podsPending, err := ks.getPodsToBeDeleted(nodeName)
+			if err != nil {
+				ks.logger.Error(fmt.Sprintf("Error fetching pods: %s", err.Error()))
+				continue
+			}
+			present := make(map[string]bool, len(podsPending))
+			for _, pod := range podsPending {
+				present[getPodKey(pod)] = true
+			}
+			for key := range pending {
+				if !present[key] {
+					delete(pending, key)
+				}
+			}
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false, nil
+			}
+			if event.Type != watch.Deleted {
+				continue
+			}
+			if pod, ok := event.Object.(*v1.Pod); ok {
+				ks.logger.Debug(fmt.Sprintf("Pod %s/%s got deleted", pod.Namespace, pod.Name))
+				delete(pending, getPodKey(*pod))
+			}
 		}
 	}
+	return true, nil
 }
 
-//startDrainNode will delete the pods running on the node passed
-//in the arugment.
-func (ks KillerService) startNodeDrain(nodeName string) error {
+//startNodeDrain evicts the pods running on the node passed in the argument
+//through the Eviction API, so that PodDisruptionBudgets are respected.
+//Pods whose eviction is still refused after the configured retries are returned
+//as blocked. On preemption the node goes away anyway, so blocked pods are deleted.
+func (ks KillerService) startNodeDrain(nodeName string, preemption bool) ([]v1.Pod, error) {
+	var blockedPods []v1.Pod
+
 	filteredPodList, err := ks.getPodsToBeDeleted(nodeName)
 	if err != nil {
-		return err
+		return blockedPods, err
 	}
 	for _, pod := range filteredPodList {
-		ks.logger.Info(fmt.Sprintf("Deleting pod %s on node %s", pod.Name, nodeName))
-
-		if err := ks.kubeClient.DeletePod(pod.Name, pod.Namespace); err != nil {
+		ks.logger.Info(fmt.Sprintf("Evicting pod %s on node %s", pod.Name, nodeName))
+
+		err := ks.evictPod(pod, preemption)
+		if apierrors.IsTooManyRequests(err) {
+			ks.logger.Warn(fmt.Sprintf("Eviction of pod %s on node %s in %s namespace blocked by PodDisruptionBudget", pod.Name, nodeName, pod.Namespace))
+			blockedPods = append(blockedPods, pod)
+			if !preemption {
+				continue
+			}
+			ks.logger.Info(fmt.Sprintf("Deleting pod %s on node %s as the node is preempted", pod.Name, nodeName))
+			err = ks.kubeClient.DeletePod(pod.Name, pod.Namespace)
+		}
+		if err != nil {
 			ks.logger.Error(
-				fmt.Sprintf("Error deleting the pod %s on node %s in %s namespace:%s",
+				fmt.Sprintf("Error evicting the pod %s on node %s in %s namespace:%s",
 					pod.Name, nodeName, pod.Namespace, err.Error(),
 				),
 			)
-			return err
+			return blockedPods, err
 		}
 	}
-	return nil
+	return blockedPods, nil
+}
+
+//evictPod evicts the pod, backing off exponentially while the API server answers
+//with 429 TooManyRequests. There are no retries on preemption as there is no time to wait.
+func (ks KillerService) evictPod(pod v1.Pod, preemption bool) error {
+	retries := ks.cp.GetInt(config.KillerEvictionRetries)
+	if preemption || retries < 0 {
+		retries = 0
+	}
+	backoff := wait.Backoff{
+		Duration: time.Millisecond * time.Duration(ks.cp.GetInt(config.KillerEvictionBackoffMs)),
+		Factor:   2,
+		Steps:    retries + 1,
+	}
+
+	var err error
+	wait.ExponentialBackoff(backoff, func() (bool, error) {
+		err = ks.kubeClient.EvictPod(pod.Name, pod.Namespace)
+		if apierrors.IsTooManyRequests(err) {
+			ks.logger.Debug(fmt.Sprintf("Eviction of pod %s in %s namespace refused, retrying: %s", pod.Name, pod.Namespace, err.Error()))
+			return false, nil
+		}
+		return true, nil
+	})
+
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+//getPodKey returns the namespaced name of the pod.
+func getPodKey(pod v1.Pod) string {
+	return fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+}
+
+func podsFromMap(podMap map[string]v1.Pod) []v1.Pod {
+	pods := make([]v1.Pod, 0, len(podMap))
+	for _, pod := range podMap {
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+//getPodNames returns the namespaced names of the pods as a comma separated string.
+func getPodNames(pods []v1.Pod) string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, getPodKey(pod))
+	}
+	sort.Strings(names)
+	return strings.Join(names, config.CommaSeparater)
 }
 
 //getZoneFromNode extracts the GCP projectID and zone
@@ -118,42 +313,92 @@ func getZoneFromNode(node v1.Node) string {
 	return zone
 }
 
+//getProjectFromNode extracts the GCP project from the given node.
+func getProjectFromNode(node v1.Node) string {
+	s := strings.Split(node.Spec.ProviderID, "/")
+	return s[2]
+}
+
+//deleteNode deletes the instance of the drained node and then its k8s node. The instance deletion is stored in
+//the state of the node, so a restart in between carries on with the k8s node. When the managed instance group
+//action is recreate, the k8s node is deleted first, as the recreated instance registers again with the same name.
 func (ks KillerService) deleteNode(node v1.Node) {
+	if ks.cp.GetString(config.GCloudMIGAction) == migActionRecreate {
+		if err := ks.deleteK8sNode(node); err != nil {
+			return
+		}
+		if err := ks.removeInstance(node, config.EventRecreateInstance, ks.gcloudClient.RecreateInstance); err != nil {
+			ks.reportOrphanInstance(node, err)
+		}
+		return
+	}
+
+	if state.Of(node) != state.InstanceDeleted {
+		// On failure the node is left drained, and its deletion is retried on the next run.
+		if err := ks.removeInstance(node, config.EventDeleteInstance, ks.gcloudClient.DeleteInstance); err != nil {
+			return
+		}
+		if err := state.Set(ks.kubeClient, node.Name, state.InstanceDeleted); err != nil {
+			ks.logger.Warn(fmt.Sprintf("Error setting the state of node %s to %s, %s", node.Name, state.InstanceDeleted, err.Error()))
+		}
+	}
+	ks.deleteK8sNode(node)
+}
+
+func (ks KillerService) deleteK8sNode(node v1.Node) error {
 	nodeDetails := getNodeDetails(node, false)
 
-	// Delete the k8s node
 	ks.logger.Info(fmt.Sprintf("Deleting node %s", node.Name))
 	if err := ks.kubeClient.DeleteNode(node.Name); err != nil {
 		ks.logger.Info(fmt.Sprintf("Error deleting the node %s", node.Name))
 		ks.notifier.Error(config.EventDeleteNode, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
-		return
+		return err
 	}
 
+	ks.logger.Info(fmt.Sprintf("Node %s is in state %s", node.Name, state.NodeDeleted))
 	ks.notifier.Info(config.EventDeleteNode, nodeDetails)
+	return nil
+}
+
+//removeInstance deletes or recreates the gcloud instance of the node.
+func (ks KillerService) removeInstance(node v1.Node, event string, remove func(zone, name string) error) error {
+	nodeDetails := getNodeDetails(node, false)
 
-	// Delete gcloud instance.
-	zone := getZoneFromNode(node)
-	ks.logger.Info(fmt.Sprintf("Deletig google instance %s", node.Name))
-	if err := ks.gcloudClient.DeleteInstance(zone, node.Name); err != nil {
+	ks.logger.Info(fmt.Sprintf("%s google instance %s", event, node.Name))
+	if err := remove(getZoneFromNode(node), node.Name); err != nil {
 		ks.logger.Error(fmt.Sprintf("Could not kill the node %s %s", node.Name, err.Error()))
-		ks.notifier.Error(config.EventDeleteInstance, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
+		ks.notifier.Error(event, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
+		return err
+	}
+	ks.notifier.Info(event, nodeDetails)
+	return nil
+}
+
+//reportOrphanInstance reports the instance of the node if it still exists after its deletion failed,
+//as its k8s node is already deleted and nothing else is going to delete it.
+func (ks KillerService) reportOrphanInstance(node v1.Node, deleteErr error) {
+	if _, err := ks.gcloudClient.GetInstance(getProjectFromNode(node), getZoneFromNode(node), node.Name); err != nil {
+		ks.logger.Warn(fmt.Sprintf("Could not check if the instance %s still exists, %s", node.Name, err.Error()))
 		return
 	}
-	ks.notifier.Info(config.EventDeleteInstance, nodeDetails)
+
+	orphanedInstances.WithLabelValues(node.Labels[ks.cp.GetString(config.NodePoolLabel)]).Inc()
+	ks.logger.Error(fmt.Sprintf("Instance %s still exists while its node got deleted", node.Name))
+	ks.notifier.Error(config.EventOrphanInstance, fmt.Sprintf("Instance: %s\nZone: %s\nProvider ID: %s\nError:%s", node.Name, getZoneFromNode(node), node.Spec.ProviderID, deleteErr.Error()))
 }
 
 func getNodeDetails(node v1.Node, preemption bool) string {
 	return fmt.Sprintf("Node: %s\n"+
 		"Preemption: %t\n"+
 		"Creation Time: %s\n"+
-		"ExpiryTime: %s",
-		node.Name, preemption, node.CreationTimestamp, node.Annotations[config.ExpiryTimeAnnotation])
+		"ExpiryTime: %s\n"+
+		"Policy: %s",
+		node.Name, preemption, node.CreationTimestamp, node.Annotations[config.ExpiryTimeAnnotation], node.Annotations[config.PolicyAnnotation])
 }
 
-//handlePreemption handles POST request on EvacuatePodsURI. This deletes the pods on the node requested.
+//EvacuatePodsFromNode cordons the node and evicts the pods on it, once the disruption coordinator lets it.
+//On expiry, pods blocked by a PodDisruptionBudget fail the evacuation so the node is not deleted.
 func (ks KillerService) EvacuatePodsFromNode(name string, timeout uint32, preemption bool) error {
-	start := time.Now()
-
 	node, err := ks.kubeClient.GetNode(name)
 
 	if err != nil {
@@ -162,19 +407,47 @@ func (ks KillerService) EvacuatePodsFromNode(name string, timeout uint32, preemp
 	}
 	nodeDetails := getNodeDetails(node, preemption)
 
+	// The drain timeout starts before waiting for a drain slot, so the wait cannot push the drain past it.
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*time.Duration(timeout))
+	defer cancel()
+
+	release, err := ks.coordinator.Acquire(ctx, node, preemption)
+	if err != nil {
+		ks.logger.Error(fmt.Sprintf("Failed to drain the node %s, %s", node.Name, err.Error()))
+		ks.notifier.Error(config.EventDrain, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
+		return err
+	}
+	defer release()
+
+	// The state is not kept on preemption, the node is going away in a few seconds anyway.
+	if !preemption {
+		state.Annotate(&node, state.Draining)
+	}
 	if err := ks.makeNodeUnschedulable(node); err != nil {
 		ks.logger.Error(fmt.Sprintf("Failed to cordon the node %s, %s", node.Name, err.Error()))
 		ks.notifier.Error(config.EventCordon, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
 		return err
 	}
 
-	if err := ks.startNodeDrain(node.Name); err != nil {
+	blockedPods, err := ks.startNodeDrain(node.Name, preemption)
+	if err != nil {
 		ks.logger.Error(fmt.Sprintf("Failed to drain the node %s, %s", node.Name, err.Error()))
 		ks.notifier.Error(config.EventDrain, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
 		return err
 	}
 
-	if err := ks.waitforDrainToFinish(node.Name, timeout); err != nil {
+	if len(blockedPods) > 0 {
+		ks.notifier.Error(config.EventPDBBlocked, fmt.Sprintf("%s\nPods:%s", nodeDetails, getPodNames(blockedPods)))
+		// On expiry the node is left cordoned and not deleted, as deleting it would violate the PodDisruptionBudgets.
+		if !preemption {
+			err := fmt.Errorf("Eviction of %d pod(s) blocked by PodDisruptionBudget", len(blockedPods))
+			ks.logger.Error(fmt.Sprintf("Failed to drain the node %s, %s", node.Name, err.Error()))
+			return err
+		}
+	}
+
+	if err := ks.waitforDrainToFinish(ctx, node.Name, timeout); err != nil {
 		ks.logger.Error(fmt.Sprintf("Error while waiting for drain on node %s, %s", node.Name, err.Error()))
 		ks.notifier.Error(config.EventDrain, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
 		return err
@@ -182,6 +455,12 @@ func (ks KillerService) EvacuatePodsFromNode(name string, timeout uint32, preemp
 	ks.logger.Info(fmt.Sprintf("Successfully drained the node %s", node.Name))
 	ks.notifier.Info(config.EventDrain, nodeDetails)
 
+	if !preemption {
+		if err := state.Set(ks.kubeClient, node.Name, state.Drained); err != nil {
+			ks.logger.Warn(fmt.Sprintf("Error setting the state of node %s to %s, %s", node.Name, state.Drained, err.Error()))
+		}
+	}
+
 	end := time.Now()
 	timeTakenToEvacuatePods := end.Sub(start).Seconds()
 
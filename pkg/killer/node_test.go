@@ -1,11 +1,20 @@
 package killer
 
 import (
+	"context"
+	"errors"
 	"time"
 
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	compute "google.golang.org/api/compute/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 func (k *KillerTestSuite) TestShouldMakeNodeUnschedulable() {
@@ -23,7 +32,7 @@ func (k *KillerTestSuite) TestShouldMakeNodeUnschedulable() {
 
 	k.k8sMock.On("UpdateNode", *expectedNode).Return(nil)
 
-	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
 
 	err := ks.makeNodeUnschedulable(node)
 	assert.Nil(k.T(), err)
@@ -58,13 +67,14 @@ func (k *KillerTestSuite) TestShouldStartNodeDrain() {
 	}
 
 	k.k8sMock.On("GetPodsInNode", "Node-1").Return(pods, nil)
-	k.k8sMock.On("DeletePod", "pod1", "ns1").Return(nil)
-	k.k8sMock.On("DeletePod", "pod2", "ns2").Return(nil)
+	k.k8sMock.On("EvictPod", "pod1", "ns1").Return(nil)
+	k.k8sMock.On("EvictPod", "pod2", "ns2").Return(nil)
 
-	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
 
-	err := ks.startNodeDrain("Node-1")
+	blockedPods, err := ks.startNodeDrain("Node-1", false)
 	assert.Nil(k.T(), err)
+	assert.Empty(k.T(), blockedPods)
 	k.k8sMock.AssertExpectations(k.T())
 }
 
@@ -96,34 +106,63 @@ func (k *KillerTestSuite) TestShouldNotDrainDaemonsetPods() {
 	}
 
 	k.k8sMock.On("GetPodsInNode", "Node-1").Return(pods, nil)
-	k.k8sMock.On("DeletePod", "pod2", "ns2").Return(nil)
+	k.k8sMock.On("EvictPod", "pod2", "ns2").Return(nil)
 
-	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
 
-	err := ks.startNodeDrain("Node-1")
+	_, err := ks.startNodeDrain("Node-1", false)
 	assert.Nil(k.T(), err)
 	k.k8sMock.AssertExpectations(k.T())
 }
 
 func (k *KillerTestSuite) TestShouldWaitforDrainingOfnodesWithTimeout() {
 	nodeName := "node-1"
-	pod1 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet"}}}}
-	pod2 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-2"}}
-	pod3 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-3"}}
-	pod4 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-4"}}
+	pod1 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "ns", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet"}}}}
+	pod2 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-2", Namespace: "ns", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet"}}}}
+	pod3 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-3", Namespace: "ns", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet"}}}}
 
-	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{pod1, pod2, pod3, pod4}, nil).After(1000 * time.Millisecond).Once()
-	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{pod1, pod2, pod3}, nil).After(1000 * time.Millisecond).Once()
-	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{pod1, pod2}, nil).After(1000 * time.Millisecond).Once()
-	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{pod1}, nil).After(1000 * time.Millisecond).Once()
-	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{}, nil).After(1000 * time.Millisecond).Once()
+	watcher := watch.NewFake()
+	k.k8sMock.On("WatchPodsInNode", nodeName).Return(watcher, nil).Once()
+	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{pod1, pod2, pod3}, nil).Once()
 
-	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock)
-	assert.Nil(k.T(), ks.waitforDrainToFinish(nodeName, 5000), "err should be nothing")
+	go func() {
+		watcher.Modify(&pod1)
+		watcher.Delete(&pod1)
+		watcher.Delete(&pod2)
+		watcher.Delete(&pod3)
+	}()
 
-	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{pod1}, nil).After(2000 * time.Millisecond).Once()
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(k.T(), ks.waitforDrainToFinish(ctx, nodeName, 5000), "err should be nothing")
 
-	assert.NotNil(k.T(), ks.waitforDrainToFinish(nodeName, 1000), "error should be something")
+	k.k8sMock.On("WatchPodsInNode", nodeName).Return(watch.NewFake(), nil).Once()
+	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{pod1, pod2}, nil).Once()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	err := ks.waitforDrainToFinish(ctx, nodeName, 500)
+	assert.NotNil(k.T(), err, "error should be something")
+	assert.Contains(k.T(), err.Error(), "ns/pod-1,ns/pod-2")
+	k.k8sMock.AssertExpectations(k.T())
+}
+
+func (k *KillerTestSuite) TestShouldRestartWatchWhenClosed() {
+	nodeName := "node-1"
+	pod1 := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "ns", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet"}}}}
+
+	closedWatcher := watch.NewFake()
+	closedWatcher.Stop()
+	k.k8sMock.On("WatchPodsInNode", nodeName).Return(closedWatcher, nil).Once()
+	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{pod1}, nil).Once()
+	k.k8sMock.On("WatchPodsInNode", nodeName).Return(watch.NewFake(), nil).Once()
+	k.k8sMock.On("GetPodsInNode", nodeName).Return([]v1.Pod{}, nil).Once()
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(k.T(), ks.waitforDrainToFinish(ctx, nodeName, 5000), "err should be nothing")
 	k.k8sMock.AssertExpectations(k.T())
 }
 
@@ -169,10 +208,229 @@ func (k *KillerTestSuite) TestShouldTriggerEvacuationOfPodsFromNode() {
 	k.k8sMock.On("UpdateNode", *expectedNode).Return(nil)
 	k.k8sMock.On("GetPodsInNode", "Node-1").Return(pods, nil).Once()
 	k.k8sMock.On("GetPodsInNode", "Node-1").Return([]v1.Pod{}, nil).Once()
-	k.k8sMock.On("DeletePod", "pod1", "ns1").Return(nil)
-	k.k8sMock.On("DeletePod", "pod2", "ns2").Return(nil)
+	k.k8sMock.On("WatchPodsInNode", "Node-1").Return(watch.NewFake(), nil)
+	k.k8sMock.On("EvictPod", "pod1", "ns1").Return(nil)
+	k.k8sMock.On("EvictPod", "pod2", "ns2").Return(nil)
 
-	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
 
 	assert.Nil(k.T(), ks.EvacuatePodsFromNode("Node-1", 10, true), "Error was not expected")
 }
+
+func (k *KillerTestSuite) TestShouldRetryEvictionBlockedByPDB() {
+	pdbErr := apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	pods := []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet"}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod2", Namespace: "ns2", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet"}}}},
+	}
+
+	k.k8sMock.On("GetPodsInNode", "Node-1").Return(pods, nil)
+	k.k8sMock.On("EvictPod", "pod1", "ns1").Return(pdbErr).Once()
+	k.k8sMock.On("EvictPod", "pod1", "ns1").Return(nil).Once()
+	k.k8sMock.On("EvictPod", "pod2", "ns2").Return(pdbErr).Times(3)
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	blockedPods, err := ks.startNodeDrain("Node-1", false)
+	assert.Nil(k.T(), err)
+	assert.Equal(k.T(), []v1.Pod{pods[1]}, blockedPods)
+	k.k8sMock.AssertExpectations(k.T())
+	k.k8sMock.AssertNotCalled(k.T(), "DeletePod", mock.Anything, mock.Anything)
+}
+
+func (k *KillerTestSuite) TestShouldDeletePodsBlockedByPDBOnPreemption() {
+	pdbErr := apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	pods := []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet"}}}},
+	}
+
+	k.k8sMock.On("GetPodsInNode", "Node-1").Return(pods, nil)
+	k.k8sMock.On("EvictPod", "pod1", "ns1").Return(pdbErr).Once()
+	k.k8sMock.On("DeletePod", "pod1", "ns1").Return(nil).Once()
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	blockedPods, err := ks.startNodeDrain("Node-1", true)
+	assert.Nil(k.T(), err)
+	assert.Equal(k.T(), pods, blockedPods)
+	k.k8sMock.AssertExpectations(k.T())
+}
+
+func (k *KillerTestSuite) TestShouldFailExpiryEvacuationBlockedByPDB() {
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1"}}
+	expectedNode := node.DeepCopy()
+	expectedNode.Spec.Unschedulable = true
+	expectedNode.Annotations = map[string]string{"silent-assassin/state": "draining"}
+	pods := []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet"}}}},
+	}
+
+	k.k8sMock.On("GetNode", "Node-1").Return(node, nil)
+	k.k8sMock.On("UpdateNode", *expectedNode).Return(nil)
+	k.k8sMock.On("GetPodsInNode", "Node-1").Return(pods, nil)
+	k.k8sMock.On("EvictPod", "pod1", "ns1").Return(apierrors.NewTooManyRequests("PDB", 0))
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.NotNil(k.T(), ks.EvacuatePodsFromNode("Node-1", 10, false), "Error was expected")
+	k.k8sMock.AssertNotCalled(k.T(), "DeletePod", mock.Anything, mock.Anything)
+}
+
+func (k *KillerTestSuite) TestShouldPostponeKillUntilAnnotatedTime() {
+	now := time.Now()
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "Node-1",
+			CreationTimestamp: metav1.NewTime(now.Add(-20 * time.Hour)),
+			Annotations:       map[string]string{"silent-assassin/postpone-kill-until": now.Add(time.Hour).Format(time.RFC1123Z)}}}
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.True(k.T(), ks.isKillPostponed(node, now, policy.Policy{DrainingTimeoutMs: 300000}, nil))
+	k.k8sMock.On("GetPodsInNode", "Node-1").Return([]v1.Pod{}, nil)
+	assert.False(k.T(), ks.isKillPostponed(node, now.Add(2*time.Hour), policy.Policy{DrainingTimeoutMs: 300000}, nil))
+}
+
+func (k *KillerTestSuite) TestShouldPostponeKillWhileProtectedPodIsRunning() {
+	now := time.Now()
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "Node-1",
+			CreationTimestamp: metav1.NewTime(now.Add(-20 * time.Hour))}}
+	protectedPod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "migration", Namespace: "ns", Annotations: map[string]string{"silent-assassin/protect": "true"}},
+		Status:     v1.PodStatus{Phase: v1.PodRunning}}
+	k.k8sMock.On("GetPodsInNode", "Node-1").Return([]v1.Pod{protectedPod}, nil)
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.True(k.T(), ks.isKillPostponed(node, now, policy.Policy{DrainingTimeoutMs: 300000}, nil))
+	// The deadline is the drain timeout, 5 minutes, before the end of the 24 hours lifetime.
+	assert.False(k.T(), ks.isKillPostponed(node, now.Add(4*time.Hour-5*time.Minute), policy.Policy{DrainingTimeoutMs: 300000}, nil))
+}
+
+func (k *KillerTestSuite) TestShouldPostponeKillUntilTheDeadlineOfThePolicy() {
+	now := time.Now()
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "Node-1",
+			CreationTimestamp: metav1.NewTime(now.Add(-20 * time.Hour))}}
+	protectedPod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "migration", Namespace: "ns", Annotations: map[string]string{"silent-assassin/protect": "true"}},
+		Status:     v1.PodStatus{Phase: v1.PodRunning}}
+	k.k8sMock.On("GetPodsInNode", "Node-1").Return([]v1.Pod{protectedPod}, nil)
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	// A drain timeout of 5 hours puts the deadline of the node an hour ago, whatever the global drain timeout.
+	assert.False(k.T(), ks.isKillPostponed(node, now, policy.Policy{DrainingTimeoutMs: 5 * 3600000}, nil))
+}
+
+func (k *KillerTestSuite) TestShouldNotPostponeKillForCompletedProtectedPod() {
+	now := time.Now()
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "Node-1",
+			CreationTimestamp: metav1.NewTime(now.Add(-20 * time.Hour))}}
+	protectedPod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "migration", Namespace: "ns", Annotations: map[string]string{"silent-assassin/protect": "true"}},
+		Status:     v1.PodStatus{Phase: v1.PodSucceeded}}
+	k.k8sMock.On("GetPodsInNode", "Node-1").Return([]v1.Pod{protectedPod}, nil)
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.False(k.T(), ks.isKillPostponed(node, now, policy.Policy{DrainingTimeoutMs: 300000}, nil))
+}
+
+func (k *KillerTestSuite) TestShouldCheckForOrphanInstanceWhenRecreationFails() {
+	k.configMock.ExpectedCalls = nil
+	k.configMock.On("GetString", config.GCloudMIGAction).Return("recreate")
+	k.configMock.On("GetString", mock.Anything).Return("debug")
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "Node-1"},
+		Spec:       v1.NodeSpec{ProviderID: "gce://project-1/asia-south1-a/Node-1"}}
+	k.k8sMock.On("DeleteNode", "Node-1").Return(nil)
+	k.gCloudMock.On("RecreateInstance", "asia-south1-a", "Node-1").Return(errors.New("QUOTA_EXCEEDED"))
+	k.gCloudMock.On("GetInstance", "project-1", "asia-south1-a", "Node-1").Return(&compute.Instance{Name: "Node-1"}, nil)
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+	ks.deleteNode(node)
+
+	k.gCloudMock.AssertExpectations(k.T())
+}
+
+func (k *KillerTestSuite) TestShouldNotCheckForOrphanInstanceWhenDeletionSucceeds() {
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "Node-1"},
+		Spec:       v1.NodeSpec{ProviderID: "gce://project-1/asia-south1-a/Node-1"}}
+	k.k8sMock.On("DeleteNode", "Node-1").Return(nil)
+	k.k8sMock.On("GetNode", "Node-1").Return(node, nil)
+	k.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	k.gCloudMock.On("DeleteInstance", "asia-south1-a", "Node-1").Return(nil)
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+	ks.deleteNode(node)
+
+	k.gCloudMock.AssertNotCalled(k.T(), "GetInstance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (k *KillerTestSuite) TestShouldKeepDrainedNodeWhenInstanceDeletionFails() {
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "Node-1", Annotations: map[string]string{"silent-assassin/state": "drained"}},
+		Spec:       v1.NodeSpec{ProviderID: "gce://project-1/asia-south1-a/Node-1"}}
+	k.gCloudMock.On("DeleteInstance", "asia-south1-a", "Node-1").Return(errors.New("QUOTA_EXCEEDED"))
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+	ks.deleteNode(node)
+
+	k.k8sMock.AssertNotCalled(k.T(), "DeleteNode", mock.Anything)
+	k.k8sMock.AssertNotCalled(k.T(), "UpdateNode", mock.Anything)
+}
+
+func (k *KillerTestSuite) TestShouldOnlyDeleteK8sNodeWhenInstanceIsDeleted() {
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "Node-1", Annotations: map[string]string{"silent-assassin/state": "instance-deleted"}},
+		Spec:       v1.NodeSpec{ProviderID: "gce://project-1/asia-south1-a/Node-1"}}
+	k.k8sMock.On("DeleteNode", "Node-1").Return(nil)
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+	ks.deleteNode(node)
+
+	k.k8sMock.AssertExpectations(k.T())
+	k.gCloudMock.AssertNotCalled(k.T(), "DeleteInstance", mock.Anything, mock.Anything)
+}
+
+func (k *KillerTestSuite) TestShouldReturnNodesInFlightWhateverTheirExpiry() {
+	inFlightNode := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "Node-1",
+			Annotations: map[string]string{"silent-assassin/state": "drained", "silent-assassin/expiry-time": time.Now().Add(time.Hour).Format(time.RFC1123Z)}}}
+	scheduledNode := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "Node-2",
+			Annotations: map[string]string{"silent-assassin/state": "scheduled", "silent-assassin/expiry-time": time.Now().Add(time.Hour).Format(time.RFC1123Z)}}}
+	k.k8sMock.On("GetNodes", "selector").Return(&v1.NodeList{Items: []v1.Node{inFlightNode, scheduledNode}}, nil)
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+	nodes, err := ks.findExpiredTimeNodes("selector", policy.Policies{}, nil)
+
+	assert.Nil(k.T(), err)
+	assert.Equal(k.T(), []v1.Node{inFlightNode}, nodes)
+}
+
+func (k *KillerTestSuite) TestShouldRecreateInstanceWhenMIGActionIsRecreate() {
+	k.configMock.ExpectedCalls = nil
+	k.configMock.On("GetString", config.GCloudMIGAction).Return("recreate")
+	k.configMock.On("GetString", mock.Anything).Return("debug")
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "Node-1"},
+		Spec:       v1.NodeSpec{ProviderID: "gce://project-1/asia-south1-a/Node-1"}}
+	k.k8sMock.On("DeleteNode", "Node-1").Return(nil)
+	k.gCloudMock.On("RecreateInstance", "asia-south1-a", "Node-1").Return(nil)
+
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+	ks.deleteNode(node)
+
+	k.gCloudMock.AssertExpectations(k.T())
+	k.gCloudMock.AssertNotCalled(k.T(), "DeleteInstance", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,73 @@
+package killer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
+	"github.com/roppenlabs/silent-assassin/pkg/state"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	//drainFailureUncordon makes the node schedulable again until its next attempt.
+	drainFailureUncordon = "uncordon"
+
+	//drainFailureRetry leaves the node cordoned until its next attempt.
+	drainFailureRetry = "retry"
+)
+
+//rollbackFailedDrain records the failed drain of the expired node in its silent-assassin/drain-failures annotation,
+//uncordons it unless the drain failure action is retry, and pushes its expiry time back by the drain failure backoff,
+//at most to its deadline, back in the scheduled state. A node which failed to drain at its deadline is escalated
+//and left in the failed state, as it will be preempted undrained.
+func (ks KillerService) rollbackFailedDrain(node v1.Node, nodePolicy policy.Policy, drainErr error) error {
+	node, err := ks.kubeClient.GetNode(node.Name)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deadline := NodeDeadline(node, nodePolicy.DrainingTimeoutMs)
+
+	failures, _ := strconv.Atoi(node.Annotations[config.DrainFailuresAnnotation])
+	failures++
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[config.DrainFailuresAnnotation] = strconv.Itoa(failures)
+
+	action := ks.cp.GetString(config.KillerDrainFailureAction)
+	if action != drainFailureRetry {
+		node.Spec.Unschedulable = false
+	}
+
+	escalate := !now.Before(deadline)
+	if escalate {
+		state.Annotate(&node, state.Failed)
+	} else {
+		state.Annotate(&node, state.Scheduled)
+		expiry := now.Add(time.Millisecond * time.Duration(ks.cp.GetUint32(config.KillerDrainFailureBackoffMs)))
+		if expiry.After(deadline) {
+			expiry = deadline
+		}
+		node.Annotations[config.ExpiryTimeAnnotation] = expiry.Format(time.RFC1123Z)
+	}
+
+	if err := ks.kubeClient.UpdateNode(node); err != nil {
+		return err
+	}
+
+	nodeDetails := fmt.Sprintf("%s\nDrain Failures: %d\nUnschedulable: %t\nError:%s", getNodeDetails(node, false), failures, node.Spec.Unschedulable, drainErr.Error())
+	if escalate {
+		ks.logger.Error(fmt.Sprintf("Node %s could not be drained before its deadline %s", node.Name, deadline.Format(time.RFC1123Z)))
+		ks.notifier.Error(config.EventEscalation, nodeDetails)
+		return nil
+	}
+
+	ks.logger.Info(fmt.Sprintf("Drain of node %s failed %d time(s), retrying at %s", node.Name, failures, node.Annotations[config.ExpiryTimeAnnotation]))
+	ks.notifier.Info(config.EventRollback, nodeDetails)
+	return nil
+}
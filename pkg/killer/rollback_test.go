@@ -0,0 +1,98 @@
+package killer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (k *KillerTestSuite) rollbackConfig(action string) {
+	k.configMock.ExpectedCalls = nil
+	k.configMock.On("GetString", config.KillerDrainFailureAction).Return(action)
+	k.configMock.On("GetUint32", config.KillerDrainFailureBackoffMs).Return(uint32(3600000))
+	k.configMock.On("GetString", mock.Anything).Return("debug")
+}
+
+func cordonedNode(age time.Duration, annotations map[string]string) v1.Node {
+	return v1.Node{
+		Spec: v1.NodeSpec{Unschedulable: true},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "Node-1",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			Annotations:       annotations}}
+}
+
+func (k *KillerTestSuite) TestShouldUncordonAndPushExpiryBackOnFailedDrain() {
+	k.rollbackConfig("")
+	node := cordonedNode(10*time.Hour, map[string]string{config.ExpiryTimeAnnotation: time.Now().Format(time.RFC1123Z)})
+	k.k8sMock.On("GetNode", "Node-1").Return(node, nil)
+	k.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	err := ks.rollbackFailedDrain(node, policy.Policy{DrainingTimeoutMs: 300000}, errors.New("drain timed out"))
+
+	assert.NoError(k.T(), err)
+	updatedNode := k.k8sMock.Calls[1].Arguments.Get(0).(v1.Node)
+	assert.False(k.T(), updatedNode.Spec.Unschedulable)
+	assert.Equal(k.T(), "1", updatedNode.Annotations[config.DrainFailuresAnnotation])
+	assert.Equal(k.T(), "scheduled", updatedNode.Annotations[config.StateAnnotation])
+	expiry, _ := time.Parse(time.RFC1123Z, updatedNode.Annotations[config.ExpiryTimeAnnotation])
+	assert.WithinDuration(k.T(), time.Now().Add(time.Hour), expiry, time.Minute)
+}
+
+func (k *KillerTestSuite) TestShouldKeepNodeCordonedAndPushExpiryToDeadlineOnRetry() {
+	k.rollbackConfig("retry")
+	node := cordonedNode(23*time.Hour+30*time.Minute, map[string]string{config.DrainFailuresAnnotation: "2"})
+	k.k8sMock.On("GetNode", "Node-1").Return(node, nil)
+	k.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	err := ks.rollbackFailedDrain(node, policy.Policy{DrainingTimeoutMs: 300000}, errors.New("drain timed out"))
+
+	assert.NoError(k.T(), err)
+	updatedNode := k.k8sMock.Calls[1].Arguments.Get(0).(v1.Node)
+	assert.True(k.T(), updatedNode.Spec.Unschedulable)
+	assert.Equal(k.T(), "3", updatedNode.Annotations[config.DrainFailuresAnnotation])
+	assert.Equal(k.T(), NodeDeadline(node, 300000).Format(time.RFC1123Z), updatedNode.Annotations[config.ExpiryTimeAnnotation])
+}
+
+func (k *KillerTestSuite) TestShouldNotPushExpiryBackAfterDeadline() {
+	k.rollbackConfig("")
+	expiry := time.Now().Add(-time.Minute).Format(time.RFC1123Z)
+	node := cordonedNode(23*time.Hour+58*time.Minute, map[string]string{config.ExpiryTimeAnnotation: expiry})
+	k.k8sMock.On("GetNode", "Node-1").Return(node, nil)
+	k.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	err := ks.rollbackFailedDrain(node, policy.Policy{DrainingTimeoutMs: 300000}, errors.New("drain timed out"))
+
+	assert.NoError(k.T(), err)
+	updatedNode := k.k8sMock.Calls[1].Arguments.Get(0).(v1.Node)
+	assert.Equal(k.T(), expiry, updatedNode.Annotations[config.ExpiryTimeAnnotation])
+	assert.Equal(k.T(), "failed", updatedNode.Annotations[config.StateAnnotation])
+}
+
+func (k *KillerTestSuite) TestShouldNotDrainEscalatedNodeOnTheNextPoll() {
+	k.rollbackConfig("")
+	node := cordonedNode(23*time.Hour+58*time.Minute, map[string]string{config.ExpiryTimeAnnotation: time.Now().Add(-time.Minute).Format(time.RFC1123Z)})
+	k.k8sMock.On("GetNode", "Node-1").Return(node, nil)
+	k.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.NoError(k.T(), ks.rollbackFailedDrain(node, policy.Policy{DrainingTimeoutMs: 300000}, errors.New("drain timed out")))
+	escalatedNode := k.k8sMock.Calls[1].Arguments.Get(0).(v1.Node)
+
+	k.k8sMock.On("GetNodes", "selector").Return(&v1.NodeList{Items: []v1.Node{escalatedNode}}, nil)
+	nodes, err := ks.findExpiredTimeNodes("selector", policy.Policies{}, nil)
+
+	assert.NoError(k.T(), err)
+	assert.Empty(k.T(), nodes)
+	k.k8sMock.AssertNotCalled(k.T(), "GetPodsInNode", mock.Anything)
+}
@@ -0,0 +1,193 @@
+package killer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	//surgeModePlaceholder adds a node by creating a pod which fits only on a new node, for the cluster autoscaler to add one.
+	surgeModePlaceholder = "placeholder"
+
+	//placeholderLabel is set on the placeholder pods.
+	placeholderLabel = "silent-assassin/surge-placeholder"
+)
+
+//surgePollInterval is the interval at which the nodes are checked for the surged node to be Ready.
+var surgePollInterval = 10 * time.Second
+
+//nodePoolLocks serialises the surges of every nodepool, so that concurrent kills in a nodepool
+//do not take the same new node for their surge.
+type nodePoolLocks struct {
+	sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newNodePoolLocks() *nodePoolLocks {
+	return &nodePoolLocks{locks: make(map[string]*sync.Mutex)}
+}
+
+//lock blocks until no other surge runs in the nodepool and returns the function unlocking it.
+func (l *nodePoolLocks) lock(nodePool string) func() {
+	l.Lock()
+	lock, ok := l.locks[nodePool]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.locks[nodePool] = lock
+	}
+	l.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+//surge adds a node to the nodepool of the expired node, in the same zone, and waits until it is Ready,
+//so the pods evicted from the expired node have somewhere to go. It does nothing when the surge mode is not set.
+//The node is added by the cluster autoscaler for a placeholder pod, so the nodepool is never resized by the
+//Killer and the autoscaler removes the extra node once it is not needed anymore.
+func (ks KillerService) surge(ctx context.Context, node v1.Node) error {
+	mode := ks.cp.GetString(config.KillerSurgeMode)
+	if mode == "" {
+		return nil
+	}
+	if mode != surgeModePlaceholder {
+		return fmt.Errorf("unknown surge mode %s, expected %s", mode, surgeModePlaceholder)
+	}
+
+	nodePool := node.Labels[config.NodePoolNameLabel]
+	zone := node.Labels[config.ZoneLabel]
+	unlock := ks.surges.lock(nodePool)
+	defer unlock()
+
+	selector := fmt.Sprintf("%s=%s,%s=%s", config.NodePoolNameLabel, nodePool, config.ZoneLabel, zone)
+	timeout := time.Duration(ks.cp.GetUint32(config.KillerSurgeTimeoutMs)) * time.Millisecond
+
+	existingNodes, err := ks.kubeClient.GetNodes(selector)
+	if err != nil {
+		return err
+	}
+
+	ks.logger.Info(fmt.Sprintf("Surging a node in nodepool %s zone %s for node %s, mode: %s", nodePool, zone, node.Name, mode))
+
+	pod, err := ks.kubeClient.CreatePod(placeholderPod(node, existingNodes.Items, ks.cp.GetString(config.KillerSurgePlaceholderNamespace), ks.cp.GetString(config.KillerSurgePlaceholderImage)))
+	if err != nil {
+		return err
+	}
+	// The placeholder only makes room, it is deleted so the evicted pods can take its place on the new node.
+	defer func() {
+		if err := ks.kubeClient.DeletePod(pod.Name, pod.Namespace); err != nil {
+			ks.logger.Error(fmt.Sprintf("Error deleting the surge placeholder pod %s/%s, %s", pod.Namespace, pod.Name, err.Error()))
+		}
+	}()
+
+	newNode, err := ks.waitForNewReadyNode(ctx, selector, existingNodes.Items, timeout)
+	if err != nil {
+		return err
+	}
+
+	ks.logger.Info(fmt.Sprintf("Surged node %s is Ready for node %s", newNode.Name, node.Name))
+	ks.notifier.Info(config.EventSurge, fmt.Sprintf("Node: %s\nSurged Node: %s\nNodepool: %s\nZone: %s", node.Name, newNode.Name, nodePool, zone))
+	return nil
+}
+
+//placeholderPod returns a pod which can only be scheduled on a new node of the nodepool and zone of the expired node.
+func placeholderPod(node v1.Node, existingNodes []v1.Node, namespace, image string) v1.Pod {
+	hostnames := []string{}
+	for _, existingNode := range existingNodes {
+		hostnames = append(hostnames, existingNode.Labels[config.HostnameLabel])
+	}
+
+	tolerations := []v1.Toleration{}
+	for _, taint := range node.Spec.Taints {
+		tolerations = append(tolerations, v1.Toleration{Key: taint.Key, Operator: v1.TolerationOpEqual, Value: taint.Value, Effect: taint.Effect})
+	}
+
+	var gracePeriod int64
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("silent-assassin-surge-%s", node.Name),
+			Namespace: namespace,
+			Labels:    map[string]string{placeholderLabel: node.Name},
+		},
+		Spec: v1.PodSpec{
+			NodeSelector: map[string]string{
+				config.NodePoolNameLabel: node.Labels[config.NodePoolNameLabel],
+				config.ZoneLabel:         node.Labels[config.ZoneLabel],
+			},
+			Affinity: &v1.Affinity{
+				NodeAffinity: &v1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+						NodeSelectorTerms: []v1.NodeSelectorTerm{{
+							MatchExpressions: []v1.NodeSelectorRequirement{{
+								Key:      config.HostnameLabel,
+								Operator: v1.NodeSelectorOpNotIn,
+								Values:   hostnames,
+							}},
+						}},
+					},
+				},
+			},
+			Tolerations:                   tolerations,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			Containers: []v1.Container{{
+				Name:  "placeholder",
+				Image: image,
+			}},
+		},
+	}
+}
+
+//waitForNewReadyNode waits until a node matching the selector, other than the existing nodes, is Ready and schedulable,
+//or until the context is done.
+func (ks KillerService) waitForNewReadyNode(ctx context.Context, selector string, existingNodes []v1.Node, timeout time.Duration) (v1.Node, error) {
+	existing := make(map[string]bool)
+	for _, node := range existingNodes {
+		existing[node.Name] = true
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var newNode v1.Node
+	err := wait.PollImmediateUntil(surgePollInterval, func() (bool, error) {
+		nodes, err := ks.kubeClient.GetNodes(selector)
+		if err != nil {
+			ks.logger.Warn(fmt.Sprintf("Error getting nodes while waiting for the surged node %s", err.Error()))
+			return false, nil
+		}
+		for _, node := range nodes.Items {
+			if !existing[node.Name] && isNodeReady(node) {
+				newNode = node
+				return true, nil
+			}
+		}
+		return false, nil
+	}, waitCtx.Done())
+	if ctx.Err() != nil {
+		return newNode, ctx.Err()
+	}
+	if err == wait.ErrWaitTimeout {
+		return newNode, fmt.Errorf("timed out after %v waiting for a new Ready node matching %s", timeout, selector)
+	}
+	return newNode, err
+}
+
+//isNodeReady returns true if the node is schedulable and its Ready condition is true.
+func isNodeReady(node v1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
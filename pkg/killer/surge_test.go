@@ -0,0 +1,119 @@
+package killer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func surgeNode(name, zone string, ready bool) v1.Node {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				config.NodePoolNameLabel: "np-1",
+				config.ZoneLabel:         zone,
+				config.HostnameLabel:     name,
+			}},
+		Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}}}
+}
+
+func (k *KillerTestSuite) surgeConfig(mode string) {
+	surgePollInterval = time.Millisecond
+	k.configMock.ExpectedCalls = nil
+	k.configMock.On("GetString", config.KillerSurgeMode).Return(mode)
+	k.configMock.On("GetString", config.KillerSurgePlaceholderNamespace).Return("kube-system")
+	k.configMock.On("GetString", config.KillerSurgePlaceholderImage).Return("k8s.gcr.io/pause:3.2")
+	k.configMock.On("GetUint32", config.KillerSurgeTimeoutMs).Return(uint32(100))
+	k.configMock.On("GetString", mock.Anything).Return("debug")
+}
+
+func (k *KillerTestSuite) TestShouldNotSurgeWhenModeIsNotSet() {
+	k.surgeConfig("")
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.NoError(k.T(), ks.surge(context.Background(), surgeNode("node-1", "asia-south1-a", true)))
+	k.k8sMock.AssertNotCalled(k.T(), "GetNodes", mock.Anything)
+}
+
+func (k *KillerTestSuite) TestShouldSurgeWithPlaceholderPod() {
+	k.surgeConfig(surgeModePlaceholder)
+	node := surgeNode("node-1", "asia-south1-a", true)
+	node.Spec.Taints = []v1.Taint{{Key: "dedicated", Value: "ingress", Effect: v1.TaintEffectNoSchedule}}
+	selector := "cloud.google.com/gke-nodepool=np-1,failure-domain.beta.kubernetes.io/zone=asia-south1-a"
+
+	k.k8sMock.On("GetNodes", selector).Return(&v1.NodeList{Items: []v1.Node{node}}, nil).Once()
+	k.k8sMock.On("GetNodes", selector).Return(&v1.NodeList{Items: []v1.Node{node, surgeNode("node-2", "asia-south1-a", true)}}, nil)
+	k.k8sMock.On("CreatePod", mock.Anything).Return(v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "silent-assassin-surge-node-1", Namespace: "kube-system"}}, nil)
+	k.k8sMock.On("DeletePod", "silent-assassin-surge-node-1", "kube-system").Return(nil)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.NoError(k.T(), ks.surge(context.Background(), node))
+
+	pod := k.k8sMock.Calls[1].Arguments.Get(0).(v1.Pod)
+	assert.Equal(k.T(), "kube-system", pod.Namespace)
+	assert.Equal(k.T(), map[string]string{config.NodePoolNameLabel: "np-1", config.ZoneLabel: "asia-south1-a"}, pod.Spec.NodeSelector)
+	assert.Equal(k.T(), []string{"node-1"}, pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Values)
+	assert.Equal(k.T(), "dedicated", pod.Spec.Tolerations[0].Key)
+	k.k8sMock.AssertCalled(k.T(), "DeletePod", "silent-assassin-surge-node-1", "kube-system")
+}
+
+func (k *KillerTestSuite) TestShouldFailSurgeWhenNoNewNodeIsReady() {
+	k.surgeConfig(surgeModePlaceholder)
+	node := surgeNode("node-1", "asia-south1-a", true)
+	selector := "cloud.google.com/gke-nodepool=np-1,failure-domain.beta.kubernetes.io/zone=asia-south1-a"
+
+	k.k8sMock.On("GetNodes", selector).Return(&v1.NodeList{Items: []v1.Node{node}}, nil)
+	k.k8sMock.On("CreatePod", mock.Anything).Return(v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "silent-assassin-surge-node-1", Namespace: "kube-system"}}, nil)
+	k.k8sMock.On("DeletePod", "silent-assassin-surge-node-1", "kube-system").Return(errors.New("not found"))
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.Error(k.T(), ks.surge(context.Background(), node))
+	k.k8sMock.AssertCalled(k.T(), "DeletePod", "silent-assassin-surge-node-1", "kube-system")
+}
+
+func (k *KillerTestSuite) TestShouldFailSurgeWithUnknownMode() {
+	// The nodepool is not resized anymore, a surge is only added through a placeholder pod.
+	k.surgeConfig("resize")
+	node := surgeNode("node-1", "asia-south1-a", true)
+	ks := NewKillerService(k.configMock, k.logger, k.k8sMock, k.gCloudMock, k.notifierMock, disruption.NewCoordinator(k.configMock, k.logger), nil, nil)
+
+	assert.Error(k.T(), ks.surge(context.Background(), node))
+	k.k8sMock.AssertNotCalled(k.T(), "GetNodes", mock.Anything)
+	k.gCloudMock.AssertNotCalled(k.T(), "SetNodePoolSize", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (k *KillerTestSuite) TestShouldSerialiseSurgesPerNodePool() {
+	locks := newNodePoolLocks()
+	unlock := locks.lock("np-1")
+
+	locked := make(chan string, 2)
+	go func() {
+		defer locks.lock("np-1")()
+		locked <- "np-1"
+	}()
+	go func() {
+		defer locks.lock("np-2")()
+		locked <- "np-2"
+	}()
+
+	assert.Equal(k.T(), "np-2", <-locked, "A surge in another nodepool should not wait")
+	select {
+	case <-locked:
+		k.T().Fatal("A second surge in np-1 should wait for the first one")
+	case <-time.After(10 * time.Millisecond):
+	}
+	unlock()
+	assert.Equal(k.T(), "np-1", <-locked)
+}
@@ -0,0 +1,114 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+//IService is a long running service that stops and marks the WaitGroup done when the context is cancelled.
+type IService interface {
+	Start(ctx context.Context, wg *sync.WaitGroup)
+}
+
+//ElectorService campaigns for a coordination.k8s.io Lease and runs the services
+//only while this replica holds it, so that a single replica disrupts nodes at a time.
+type ElectorService struct {
+	cp           config.IProvider
+	logger       logger.IZapLogger
+	leasesGetter coordinationv1.LeasesGetter
+	notifier     notifier.INotifierClient
+	services     []IService
+}
+
+func NewElectorService(cp config.IProvider, zl logger.IZapLogger, lg coordinationv1.LeasesGetter, nf notifier.INotifierClient, services ...IService) ElectorService {
+	return ElectorService{
+		cp:           cp,
+		logger:       zl,
+		leasesGetter: lg,
+		notifier:     nf,
+		services:     services,
+	}
+}
+
+//Start campaigns for the lease until the context is cancelled. When the lease is lost, the services
+//are stopped and the replica campaigns again once they have shut down.
+func (es ElectorService) Start(ctx context.Context, wg *sync.WaitGroup) {
+	identity, err := os.Hostname()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      es.cp.GetString(config.LeaderElectionLeaseName),
+			Namespace: es.cp.GetString(config.LeaderElectionNamespace),
+		},
+		Client: es.leasesGetter,
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	es.logger.Info(fmt.Sprintf("Starting leader election - Lease : %s/%s Identity : %s", lock.LeaseMeta.Namespace, lock.LeaseMeta.Name, identity))
+
+	for {
+		servicesWg := &sync.WaitGroup{}
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   time.Millisecond * time.Duration(es.cp.GetInt(config.LeaderElectionLeaseDurationMs)),
+			RenewDeadline:   time.Millisecond * time.Duration(es.cp.GetInt(config.LeaderElectionRenewDeadlineMs)),
+			RetryPeriod:     time.Millisecond * time.Duration(es.cp.GetInt(config.LeaderElectionRetryPeriodMs)),
+			ReleaseOnCancel: true,
+			Name:            lock.LeaseMeta.Name,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leaderCtx context.Context) {
+					es.lead(leaderCtx, servicesWg, identity)
+				},
+				OnStoppedLeading: func() {
+					es.logger.Info(fmt.Sprintf("%s stopped leading", identity))
+				},
+				OnNewLeader: func(leader string) {
+					es.logger.Info(fmt.Sprintf("Current leader : %s", leader))
+				},
+			},
+		})
+
+		// Wait for the services to shut down, so they never run twice in this replica.
+		servicesWg.Wait()
+
+		select {
+		case <-ctx.Done():
+			es.logger.Info("Shutting down leader election")
+			wg.Done()
+			return
+		default:
+			es.logger.Error(fmt.Sprintf("%s lost the lease, campaigning again", identity))
+			es.notifier.Error(config.EventLeaderElection, fmt.Sprintf("Replica %s lost the lease %s/%s", identity, lock.LeaseMeta.Namespace, lock.LeaseMeta.Name))
+		}
+	}
+}
+
+//lead starts the services with a context that is cancelled when the lease is lost.
+func (es ElectorService) lead(ctx context.Context, wg *sync.WaitGroup, identity string) {
+	if ctx.Err() != nil {
+		return
+	}
+	es.logger.Info(fmt.Sprintf("%s started leading, starting services", identity))
+	es.notifier.Info(config.EventLeaderElection, fmt.Sprintf("Replica %s started leading", identity))
+
+	for _, service := range es.services {
+		wg.Add(1)
+		go service.Start(ctx, wg)
+	}
+}
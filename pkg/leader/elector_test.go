@@ -0,0 +1,69 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type serviceMock struct {
+	started chan struct{}
+}
+
+func (s serviceMock) Start(ctx context.Context, wg *sync.WaitGroup) {
+	s.started <- struct{}{}
+	<-ctx.Done()
+	wg.Done()
+}
+
+type ElectorTestSuite struct {
+	suite.Suite
+	configMock   *config.ProviderMock
+	logger       logger.IZapLogger
+	notifierMock *notifier.NotifierClientMock
+}
+
+func (et *ElectorTestSuite) SetupTest() {
+	et.configMock = new(config.ProviderMock)
+	et.notifierMock = new(notifier.NotifierClientMock)
+	et.configMock.On("GetString", mock.Anything).Return("debug")
+	et.logger = logger.Init(et.configMock)
+}
+
+func (et *ElectorTestSuite) TestShouldRunServicesWhileLeading() {
+	service := serviceMock{started: make(chan struct{}, 2)}
+	es := NewElectorService(et.configMock, et.logger, nil, et.notifierMock, service, service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	es.lead(ctx, wg, "replica-1")
+
+	<-service.started
+	<-service.started
+	cancel()
+	wg.Wait()
+}
+
+func (et *ElectorTestSuite) TestShouldNotStartServicesAfterLeaseIsLost() {
+	service := serviceMock{started: make(chan struct{}, 1)}
+	es := NewElectorService(et.configMock, et.logger, nil, et.notifierMock, service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	wg := &sync.WaitGroup{}
+	es.lead(ctx, wg, "replica-1")
+	wg.Wait()
+
+	assert.Equal(et.T(), 0, len(service.started), "Service should not be started")
+}
+
+func TestElectorTestSuite(t *testing.T) {
+	suite.Run(t, new(ElectorTestSuite))
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"fmt"
+
 	"github.com/roppenlabs/silent-assassin/pkg/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -36,11 +38,17 @@ func (l ZapLogger) Error(message string) {
 	l.Logger.Error(message)
 }
 
+//Init builds the logger at the configured level, which follows the changes of the configuration.
 func Init(cp config.IProvider) ZapLogger {
 
+	level := getLogLevel(cp.GetString(config.LogLevel))
+	cp.OnChange(func() {
+		level.SetLevel(getLogLevel(cp.GetString(config.LogLevel)).Level())
+	})
+
 	config := zap.Config{
 		Encoding:         "json",
-		Level:            getLogLevel(cp.GetString(config.LogLevel)),
+		Level:            level,
 		OutputPaths:      []string{"stderr"},
 		ErrorOutputPaths: []string{"stderr"},
 		EncoderConfig: zapcore.EncoderConfig{
@@ -60,6 +68,16 @@ func Init(cp config.IProvider) ZapLogger {
 	return ZapLogger{Logger: logger}
 }
 
+//ValidateLevel returns an error if the level is not one of debug, info, warn and error. An empty level is info.
+func ValidateLevel(level string) error {
+	switch level {
+	case "", "debug", "info", "warn", "error":
+		return nil
+	default:
+		return fmt.Errorf("invalid log level %s, expected debug, info, warn or error", level)
+	}
+}
+
 func getLogLevel(level string) zap.AtomicLevel {
 	switch level {
 	case "debug":
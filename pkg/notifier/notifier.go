@@ -19,6 +19,7 @@ const (
 
 type Notification struct {
 	Severity severity
+	Channel  string
 	Title    string
 	Details  string
 }
@@ -26,26 +27,50 @@ type Notification struct {
 //NotificationService is a notification engine
 type NotificationService struct {
 	notificationEvent chan Notification
-	provider          Provider
+	provider          *providerHolder
+	channel           string
 }
 
-//NewNotifier creates a new notifier client
+//providerHolder holds the provider built from the current configuration, shared by the copies of the service.
+type providerHolder struct {
+	sync.RWMutex
+	provider Provider
+}
+
+//NewNotifier creates a new notifier client, whose provider is built again when the configuration changes.
 func NewNotificationService(cp config.IProvider, zl logger.IZapLogger) NotificationService {
-	var err error
-	var provider Provider
-	provider = noProvider{}
-	if cp.GetString(config.SlackWebhookURL) != "" {
-		provider, err = NewSlackClient(cp)
+	n := NotificationService{
+		provider:          &providerHolder{provider: newProvider(cp, zl)},
+		notificationEvent: make(chan Notification),
+	}
+
+	cp.OnChange(func() {
+		provider := newProvider(cp, zl)
+		n.provider.Lock()
+		n.provider.provider = provider
+		n.provider.Unlock()
+	})
+	return n
+}
+
+//newProvider builds the Slack provider when a webhook is configured.
+func newProvider(cp config.IProvider, zl logger.IZapLogger) Provider {
+	if cp.GetString(config.SlackWebhookURL) == "" {
+		return noProvider{}
 	}
+	provider, err := NewSlackClient(cp)
 	if err != nil {
 		zl.Error(fmt.Sprintf("Error configuring Slack: %s", err))
-		provider = noProvider{}
+		return noProvider{}
 	}
+	return provider
+}
 
-	return NotificationService{
-		provider:          provider,
-		notificationEvent: make(chan Notification),
-	}
+//getProvider returns the provider built from the current configuration.
+func (n NotificationService) getProvider() Provider {
+	n.provider.RLock()
+	defer n.provider.RUnlock()
+	return n.provider.provider
 }
 
 //Start starts the notifier service
@@ -56,7 +81,7 @@ func (n NotificationService) Start(ctx context.Context, wg *sync.WaitGroup) {
 			wg.Done()
 			return
 		case data := <-n.notificationEvent:
-			go n.provider.push(data.Severity, data.Title, data.Details)
+			go n.getProvider().push(data.Severity, data.Channel, data.Title, data.Details)
 		}
 	}
 }
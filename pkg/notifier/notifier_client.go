@@ -3,12 +3,14 @@ package notifier
 type INotifierClient interface {
 	Info(event, details string)
 	Error(event, details string)
+	Route(channel string) INotifierClient
 }
 
 //publish publishes the notifications to the notificationEventchannel od Notifier struct.
 func (n NotificationService) publish(severity severity, event, details string) {
 	data := Notification{
 		Severity: severity,
+		Channel:  n.channel,
 		Title:    event,
 		Details:  details,
 	}
@@ -25,3 +27,10 @@ func (n NotificationService) Info(event, details string) {
 func (n NotificationService) Error(event, details string) {
 	n.publish(DANGER, event, details)
 }
+
+//Route returns a client which sends the notifications to the channel instead of the configured one.
+//An empty channel keeps the configured one.
+func (n NotificationService) Route(channel string) INotifierClient {
+	n.channel = channel
+	return n
+}
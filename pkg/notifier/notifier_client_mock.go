@@ -11,3 +11,7 @@ func (m *NotifierClientMock) Info(event, details string) {
 
 func (m *NotifierClientMock) Error(event, details string) {
 }
+
+func (m *NotifierClientMock) Route(channel string) INotifierClient {
+	return m
+}
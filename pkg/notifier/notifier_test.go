@@ -32,7 +32,7 @@ func (suite *notifierTestSuit) TestSouldInitializeSlackClient() {
 
 	n := NewNotificationService(suite.configMock, suite.logger)
 
-	assert.IsType(suite.T(), Slack{}, n.provider)
+	assert.IsType(suite.T(), Slack{}, n.getProvider())
 }
 
 func TestNotifierTestSuite(t *testing.T) {
@@ -1,7 +1,7 @@
 package notifier
 
 // Provider is a Messaging interface.
-// Currently Slack struct implements this.
+// Currently Slack struct implements this. An empty channel is the configured channel of the provider.
 type Provider interface {
-	push(severity severity, title, details string) error
+	push(severity severity, channel, title, details string) error
 }
@@ -89,7 +89,7 @@ func NewSlackClient(cp config.IProvider) (Slack, error) {
 }
 
 //createPayload creates request payload for slack webhook
-func (s Slack) createPayload(severity severity, title, details string) slackPayload {
+func (s Slack) createPayload(severity severity, channel, title, details string) slackPayload {
 	titleBlock := slackBlock{
 		BlockType: "section",
 		Text: &slackText{
@@ -111,8 +111,13 @@ func (s Slack) createPayload(severity severity, title, details string) slackPayl
 		Blocks:   []slackBlock{titleBlock, detailBlock},
 		Severity: severity,
 	}
+	if channel == "" {
+		channel = s.channel
+	} else {
+		channel = fmt.Sprintf("#%s", channel)
+	}
 	payload := slackPayload{
-		Channel:     s.channel,
+		Channel:     channel,
 		Username:    s.username,
 		IconURL:     s.iconURL,
 		Attachments: []slackAttachment{attachment},
@@ -156,9 +161,9 @@ func (s Slack) postMessage(address string, payload interface{}) error {
 }
 
 //push implements Provider interface. Sends the notification to Slack webhook.
-func (s Slack) push(severity severity, title, details string) error {
+func (s Slack) push(severity severity, channel, title, details string) error {
 
-	payload := s.createPayload(severity, title, details)
+	payload := s.createPayload(severity, channel, title, details)
 	err := s.postMessage(s.url, payload)
 
 	return err
@@ -0,0 +1,126 @@
+package pause
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//The keys of the pause ConfigMap, which can also be edited with kubectl.
+const (
+	pausedKey = "paused"
+	sinceKey  = "since"
+	untilKey  = "until"
+	userKey   = "user"
+	reasonKey = "reason"
+)
+
+//State is the pause of the disruptions, held in the pause ConfigMap so every replica and the CLI share it.
+//While it is active the Killer and the Shifter skip their work, the Spotter and the preemptions are not paused.
+type State struct {
+	Paused bool       `json:"paused"`
+	Since  *time.Time `json:"since,omitempty"`
+	Until  *time.Time `json:"until,omitempty"`
+	User   string     `json:"user,omitempty"`
+	Reason string     `json:"reason,omitempty"`
+}
+
+//Active returns true if the disruptions are paused at now, a pause ends at its Until time.
+func (s State) Active(now time.Time) bool {
+	return s.Paused && (s.Until == nil || now.Before(*s.Until))
+}
+
+//String describes the pause for the logs and the notifications.
+func (s State) String() string {
+	if !s.Paused {
+		return "not paused"
+	}
+	description := "paused"
+	if s.User != "" {
+		description += fmt.Sprintf(" by %s", s.User)
+	}
+	if s.Since != nil {
+		description += fmt.Sprintf(" since %s", s.Since.Format(time.RFC1123Z))
+	}
+	if s.Until != nil {
+		description += fmt.Sprintf(" until %s", s.Until.Format(time.RFC1123Z))
+	}
+	if s.Reason != "" {
+		description += fmt.Sprintf(", reason: %s", s.Reason)
+	}
+	return description
+}
+
+//Load reads the pause from the pause ConfigMap. A missing ConfigMap is not a pause.
+func Load(cp config.IProvider, kc k8s.IKubernetesClient) (State, error) {
+	name, namespace := cp.GetString(config.PauseConfigMap), cp.GetString(config.PauseNamespace)
+	configMap, err := kc.GetConfigMap(name, namespace)
+	if apierrors.IsNotFound(err) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("error reading pause ConfigMap %s/%s: %s", namespace, name, err.Error())
+	}
+
+	s := State{
+		Paused: configMap.Data[pausedKey] == "true",
+		User:   configMap.Data[userKey],
+		Reason: configMap.Data[reasonKey],
+	}
+	if s.Since, err = parseTime(configMap.Data[sinceKey]); err != nil {
+		return State{}, fmt.Errorf("invalid %s of pause ConfigMap %s/%s: %s", sinceKey, namespace, name, err.Error())
+	}
+	if s.Until, err = parseTime(configMap.Data[untilKey]); err != nil {
+		return State{}, fmt.Errorf("invalid %s of pause ConfigMap %s/%s: %s", untilKey, namespace, name, err.Error())
+	}
+	return s, nil
+}
+
+//Save writes the pause to the pause ConfigMap, which is created if it does not exist.
+//The ConfigMap is written in dry run mode too, as a pause does not disrupt any node.
+func Save(cp config.IProvider, kc k8s.IKubernetesClient, s State) error {
+	name, namespace := cp.GetString(config.PauseConfigMap), cp.GetString(config.PauseNamespace)
+	data := map[string]string{pausedKey: fmt.Sprintf("%t", s.Paused)}
+	if s.Paused {
+		data[sinceKey] = formatTime(s.Since)
+		data[untilKey] = formatTime(s.Until)
+		data[userKey] = s.User
+		data[reasonKey] = s.Reason
+	}
+
+	configMap, err := kc.GetConfigMap(name, namespace)
+	if apierrors.IsNotFound(err) {
+		configMap = v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}, Data: data}
+		err = kc.CreateConfigMap(configMap)
+	} else if err == nil {
+		configMap.Data = data
+		err = kc.UpdateConfigMap(configMap)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing pause ConfigMap %s/%s: %s", namespace, name, err.Error())
+	}
+	return nil
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,214 @@
+package pause
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+)
+
+//ErrUntilInPast is returned for a pause which would have already ended.
+var ErrUntilInPast = errors.New("the pause has to end in the future")
+
+//IPauser tells the services which disrupt nodes whether they are paused.
+type IPauser interface {
+	//Paused returns true if the disruptions are paused. A pause which reached its Until time is ended.
+	Paused(component string) bool
+	//Defer records the work the component skipped during the pause, like "the kill of" its nodes.
+	Defer(component, action string, items ...string)
+}
+
+//Status is the pause with the work deferred by the services of this replica, only the leader defers work.
+type Status struct {
+	State
+	Active   bool                `json:"active"`
+	Deferred map[string][]string `json:"deferred,omitempty"`
+}
+
+//deferral is the work a component skipped during the pause.
+type deferral struct {
+	action string
+	items  []string
+}
+
+//tracker follows the pause seen by the services of this replica, so every pause, deferral
+//and resume is notified once. It is shared between the copies of the PauseService.
+type tracker struct {
+	sync.Mutex
+	paused   bool
+	state    State
+	deferred map[string]*deferral
+}
+
+type PauseService struct {
+	cp         config.IProvider
+	logger     logger.IZapLogger
+	kubeClient k8s.IKubernetesClient
+	notifier   notifier.INotifierClient
+	tracker    *tracker
+}
+
+func NewPauseService(cp config.IProvider, zl logger.IZapLogger, kc k8s.IKubernetesClient, nf notifier.INotifierClient) PauseService {
+	return PauseService{
+		cp:         cp,
+		logger:     zl,
+		kubeClient: kc,
+		notifier:   nf,
+		tracker:    &tracker{deferred: make(map[string]*deferral)},
+	}
+}
+
+//Pause pauses the disruptions until the until time, or until they are resumed when until is nil.
+//Pausing again while paused keeps the start of the pause, so its deferred work is kept.
+func (ps PauseService) Pause(until *time.Time, user, reason string) (State, error) {
+	now := time.Now()
+	if until != nil && !until.After(now) {
+		return State{}, ErrUntilInPast
+	}
+	current, err := Load(ps.cp, ps.kubeClient)
+	if err != nil {
+		return State{}, err
+	}
+
+	since := &now
+	if current.Active(now) && current.Since != nil {
+		since = current.Since
+	}
+	s := State{Paused: true, Since: since, Until: until, User: user, Reason: reason}
+	return s, Save(ps.cp, ps.kubeClient, s)
+}
+
+//Resume ends the pause. The deferred work is reported by the leader on its next poll.
+func (ps PauseService) Resume() error {
+	return Save(ps.cp, ps.kubeClient, State{})
+}
+
+//Status returns the pause and the work deferred by the services of this replica.
+func (ps PauseService) Status() (Status, error) {
+	s, err := Load(ps.cp, ps.kubeClient)
+	if err != nil {
+		return Status{}, err
+	}
+	status := Status{State: s, Active: s.Active(time.Now())}
+
+	ps.tracker.Lock()
+	defer ps.tracker.Unlock()
+	if status.Active && len(ps.tracker.deferred) > 0 {
+		status.Deferred = make(map[string][]string)
+		for component, d := range ps.tracker.deferred {
+			status.Deferred[component] = d.items
+		}
+	}
+	return status, nil
+}
+
+//Paused returns true if the disruptions are paused. The component is reported as paused in the logs.
+//The disruptions are skipped when the pause cannot be read too, as they may be paused.
+func (ps PauseService) Paused(component string) bool {
+	ps.tracker.Lock()
+	defer ps.tracker.Unlock()
+
+	now := time.Now()
+	s, err := Load(ps.cp, ps.kubeClient)
+	if err != nil {
+		ps.logger.Error(fmt.Sprintf("%s: %s, skipping the disruptions", component, err.Error()))
+		ps.notifier.Error(config.EventPause, fmt.Sprintf("%s: %s, skipping the disruptions", component, err.Error()))
+		return true
+	}
+
+	if s.Active(now) {
+		if !ps.tracker.paused || !sameTime(ps.tracker.state.Since, s.Since) {
+			ps.tracker.deferred = make(map[string]*deferral)
+			ps.logger.Info(fmt.Sprintf("Disruptions %s", s))
+			ps.notifier.Info(config.EventPause, fmt.Sprintf("Disruptions %s", s))
+		}
+		ps.tracker.paused = true
+		ps.tracker.state = s
+		ps.logger.Info(fmt.Sprintf("%s is paused, disruptions %s", component, s))
+		return true
+	}
+
+	if s.Paused {
+		ps.logger.Info(fmt.Sprintf("The pause ended at %s", s.Until.Format(time.RFC1123Z)))
+		if err := Save(ps.cp, ps.kubeClient, State{}); err != nil {
+			ps.logger.Error(fmt.Sprintf("Error ending the pause %s", err.Error()))
+		}
+	}
+	if ps.tracker.paused {
+		ps.resumed()
+	}
+	return false
+}
+
+//Defer records the work the component skipped during the pause. Every new item is notified once per pause,
+//a component without items is notified the first time it defers its work.
+func (ps PauseService) Defer(component, action string, items ...string) {
+	ps.tracker.Lock()
+	defer ps.tracker.Unlock()
+
+	d, ok := ps.tracker.deferred[component]
+	if !ok {
+		d = &deferral{action: action}
+		ps.tracker.deferred[component] = d
+	}
+	deferred := make(map[string]bool)
+	for _, item := range d.items {
+		deferred[item] = true
+	}
+	var added []string
+	for _, item := range items {
+		if !deferred[item] {
+			deferred[item] = true
+			added = append(added, item)
+		}
+	}
+	d.items = append(d.items, added...)
+
+	ps.logger.Info(describe(component, action, items))
+	if len(added) > 0 {
+		ps.notifier.Info(config.EventPause, fmt.Sprintf("%s, disruptions %s", describe(component, action, added), ps.tracker.state))
+	} else if !ok && len(items) == 0 {
+		ps.notifier.Info(config.EventPause, fmt.Sprintf("%s, disruptions %s", describe(component, action, nil), ps.tracker.state))
+	}
+}
+
+//resumed reports the end of the pause with the work deferred during it, which is done from now on.
+func (ps PauseService) resumed() {
+	details := "Disruptions resumed"
+	components := make([]string, 0, len(ps.tracker.deferred))
+	for component := range ps.tracker.deferred {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		d := ps.tracker.deferred[component]
+		details += "\n" + describe(component, d.action, d.items)
+	}
+	ps.logger.Info(details)
+	ps.notifier.Info(config.EventPause, details)
+
+	ps.tracker.paused = false
+	ps.tracker.state = State{}
+	ps.tracker.deferred = make(map[string]*deferral)
+}
+
+func describe(component, action string, items []string) string {
+	if len(items) == 0 {
+		return fmt.Sprintf("%s deferred %s", component, action)
+	}
+	return fmt.Sprintf("%s deferred %s %s", component, action, strings.Join(items, ", "))
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
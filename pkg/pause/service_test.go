@@ -0,0 +1,115 @@
+package pause
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+//recordingNotifier records the notifications, the NotifierClientMock does not.
+type recordingNotifier struct {
+	infos  []string
+	errors []string
+}
+
+func (n *recordingNotifier) Info(event, details string) {
+	n.infos = append(n.infos, event+": "+details)
+}
+
+func (n *recordingNotifier) Error(event, details string) {
+	n.errors = append(n.errors, event+": "+details)
+}
+
+func (n *recordingNotifier) Route(channel string) notifier.INotifierClient {
+	return n
+}
+
+type PauseTestSuite struct {
+	suite.Suite
+	configMock *config.ProviderMock
+	k8sMock    *k8s.K8sClientMock
+	notifier   *recordingNotifier
+	service    PauseService
+}
+
+func (pt *PauseTestSuite) SetupTest() {
+	pt.configMock = new(config.ProviderMock)
+	pt.k8sMock = new(k8s.K8sClientMock)
+	pt.notifier = &recordingNotifier{}
+	pt.configMock.On("GetString", config.PauseConfigMap).Return("silent-assassin-pause")
+	pt.configMock.On("GetString", config.PauseNamespace).Return("default")
+	pt.configMock.On("GetString", mock.Anything).Return("error")
+	pt.service = NewPauseService(pt.configMock, logger.Init(pt.configMock), pt.k8sMock, pt.notifier)
+}
+
+func (pt *PauseTestSuite) configMap(data map[string]string) v1.ConfigMap {
+	return v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "silent-assassin-pause", Namespace: "default"}, Data: data}
+}
+
+func (pt *PauseTestSuite) TestShouldCreatePauseConfigMapAndRejectPastUntil() {
+	pt.k8sMock.On("GetConfigMap", "silent-assassin-pause", "default").Return(v1.ConfigMap{}, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "silent-assassin-pause"))
+	pt.k8sMock.On("CreateConfigMap", mock.Anything).Return(nil)
+
+	past := time.Now().Add(-time.Minute)
+	_, err := pt.service.Pause(&past, "alice", "upgrade")
+	assert.Equal(pt.T(), ErrUntilInPast, err)
+
+	until := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	s, err := pt.service.Pause(&until, "alice", "upgrade")
+	assert.Nil(pt.T(), err)
+	assert.True(pt.T(), s.Active(time.Now()))
+	assert.False(pt.T(), s.Active(until))
+
+	created := pt.k8sMock.Calls[len(pt.k8sMock.Calls)-1].Arguments.Get(0).(v1.ConfigMap)
+	assert.Equal(pt.T(), "silent-assassin-pause", created.Name)
+	assert.Equal(pt.T(), "true", created.Data[pausedKey])
+	assert.Equal(pt.T(), until.Format(time.RFC3339), created.Data[untilKey])
+	assert.Equal(pt.T(), "alice", created.Data[userKey])
+	assert.Equal(pt.T(), "upgrade", created.Data[reasonKey])
+}
+
+func (pt *PauseTestSuite) TestShouldNotifyDeferredWorkOnceAndReportItWhenThePauseEnds() {
+	since := time.Now().Add(-time.Hour)
+	until := time.Now().Add(time.Hour)
+	paused := pt.configMap(map[string]string{pausedKey: "true", sinceKey: since.Format(time.RFC3339), untilKey: until.Format(time.RFC3339), userKey: "alice"})
+	pt.k8sMock.On("GetConfigMap", "silent-assassin-pause", "default").Return(paused, nil).Times(3)
+
+	assert.True(pt.T(), pt.service.Paused("Killer"))
+	pt.service.Defer("Killer", "the kill of", "node-1")
+	assert.True(pt.T(), pt.service.Paused("Shifter"))
+	pt.service.Defer("Shifter", "the shift of the node pools")
+	pt.service.Defer("Shifter", "the shift of the node pools")
+	assert.True(pt.T(), pt.service.Paused("Killer"))
+	pt.service.Defer("Killer", "the kill of", "node-1", "node-2")
+	assert.Equal(pt.T(), 4, len(pt.notifier.infos))
+	assert.Contains(pt.T(), pt.notifier.infos[0], "PAUSE: Disruptions paused by alice")
+	assert.Contains(pt.T(), pt.notifier.infos[1], "PAUSE: Killer deferred the kill of node-1, disruptions paused by alice")
+	assert.Contains(pt.T(), pt.notifier.infos[2], "PAUSE: Shifter deferred the shift of the node pools")
+	assert.Contains(pt.T(), pt.notifier.infos[3], "PAUSE: Killer deferred the kill of node-2, disruptions paused by alice")
+
+	// The pause ended, the leader clears the ConfigMap and reports what was deferred.
+	ended := time.Now().Add(-time.Minute)
+	expired := pt.configMap(map[string]string{pausedKey: "true", sinceKey: since.Format(time.RFC3339), untilKey: ended.Format(time.RFC3339)})
+	pt.k8sMock.On("GetConfigMap", "silent-assassin-pause", "default").Return(expired, nil)
+	pt.k8sMock.On("UpdateConfigMap", pt.configMap(map[string]string{pausedKey: "false"})).Return(nil)
+
+	assert.False(pt.T(), pt.service.Paused("Killer"))
+	assert.Equal(pt.T(), "PAUSE: Disruptions resumed\nKiller deferred the kill of node-1, node-2\nShifter deferred the shift of the node pools", pt.notifier.infos[4])
+	pt.k8sMock.AssertNumberOfCalls(pt.T(), "UpdateConfigMap", 1)
+	assert.Empty(pt.T(), pt.notifier.errors)
+}
+
+func TestPauseTestSuite(t *testing.T) {
+	suite.Run(t, new(PauseTestSuite))
+}
@@ -0,0 +1,308 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/calendar"
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultPolicyName is the name of the policy built from the global configuration.
+// It applies to the nodes that no configured policy matches.
+const DefaultPolicyName = "default"
+
+//Policy sets the kill windows, drain timeout and concurrency for the nodes it matches.
+//A node matches a policy when it is in one of the NodePools and matches the LabelSelector,
+//any of the two can be left empty.
+//WhiteListDayIntervalHours replaces the WhiteListIntervalHours on the days of the week it names.
+//The ShiftWhiteList fields replace the whitelist of the Shifter for the nodes of the policy, and the
+//notifications about the nodes of the policy go to the SlackChannel when it is set.
+type Policy struct {
+	Name                           string            `mapstructure:"name"`
+	NodePools                      []string          `mapstructure:"nodepools"`
+	LabelSelector                  string            `mapstructure:"label_selector"`
+	WhiteListIntervalHours         string            `mapstructure:"white_list_interval_hours"`
+	WhiteListDayIntervalHours      map[string]string `mapstructure:"white_list_day_interval_hours"`
+	WhiteListTimezone              string            `mapstructure:"white_list_timezone"`
+	ShiftWhiteListIntervalHours    string            `mapstructure:"shift_white_list_interval_hours"`
+	ShiftWhiteListDayIntervalHours map[string]string `mapstructure:"shift_white_list_day_interval_hours"`
+	ShiftWhiteListTimezone         string            `mapstructure:"shift_white_list_timezone"`
+	DrainingTimeoutMs              uint32            `mapstructure:"draining_timeout_ms"`
+	Concurrency                    int               `mapstructure:"concurrency"`
+	SlackChannel                   string            `mapstructure:"slack_channel"`
+	selector                       labels.Selector
+	location                       *time.Location
+	dayIntervals                   map[time.Weekday][]string
+	shiftLocation                  *time.Location
+	shiftDayIntervals              map[time.Weekday][]string
+	nodePoolLabel                  string
+}
+
+//Policies holds the configured policies in the order of precedence and the default policy.
+type Policies struct {
+	items         []Policy
+	defaultPolicy Policy
+	rejected      map[string]error
+}
+
+//Load reads the policies section of the configuration and the policy resources listed by the source,
+//which can be nil. The resources are matched before the configured policies. Values not set in a policy
+//are taken from the global configuration. A configured policy which is not valid fails the load, while
+//a resource which is not valid is left out and reported by Rejected.
+func Load(cp config.IProvider, src ISource) (Policies, error) {
+	defaultPolicy := Policy{
+		Name:                      DefaultPolicyName,
+		WhiteListIntervalHours:    strings.Join(cp.SplitStringToSlice(config.SpotterWhiteListIntervalHours, config.CommaSeparater), config.CommaSeparater),
+		WhiteListDayIntervalHours: cp.GetStringMapString(config.SpotterWhiteListDayIntervalHours),
+		WhiteListTimezone:         cp.GetString(config.SpotterWhiteListTimezone),
+		DrainingTimeoutMs:         cp.GetUint32(config.KillerDrainingTimeoutWhenNodeExpiredMs),
+		Concurrency:               1,
+		selector:                  labels.Everything(),
+		nodePoolLabel:             cp.GetString(config.NodePoolLabel),
+	}
+	policies := Policies{defaultPolicy: defaultPolicy, rejected: make(map[string]error)}
+
+	location, err := time.LoadLocation(defaultPolicy.WhiteListTimezone)
+	if err != nil {
+		return policies, fmt.Errorf("invalid %s: %s", config.SpotterWhiteListTimezone, err.Error())
+	}
+	defaultPolicy.location = location
+
+	defaultPolicy.dayIntervals, err = calendar.ParseDayIntervals(defaultPolicy.WhiteListDayIntervalHours)
+	if err != nil {
+		return policies, fmt.Errorf("invalid %s: %s", config.SpotterWhiteListDayIntervalHours, err.Error())
+	}
+	policies.defaultPolicy = defaultPolicy
+
+	var items []Policy
+	if err := cp.UnmarshalKey(config.Policies, &items); err != nil {
+		return policies, fmt.Errorf("error reading %s: %s", config.Policies, err.Error())
+	}
+
+	names := map[string]bool{DefaultPolicyName: true}
+	for _, p := range items {
+		if p.Name == "" {
+			return policies, errors.New("policy name cannot be empty")
+		}
+		if names[p.Name] {
+			return policies, fmt.Errorf("policy name %s is not unique", p.Name)
+		}
+		names[p.Name] = true
+	}
+
+	var resources []SilentAssassinPolicy
+	if src != nil {
+		resources = src.ListResources()
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Spec.Priority != resources[j].Spec.Priority {
+			return resources[i].Spec.Priority > resources[j].Spec.Priority
+		}
+		return resources[i].Name < resources[j].Name
+	})
+
+	for _, r := range resources {
+		if names[r.Name] {
+			policies.rejected[r.Name] = fmt.Errorf("policy name %s is not unique", r.Name)
+			continue
+		}
+		p, err := fromResource(r)
+		if err == nil {
+			p, err = complete(p, defaultPolicy)
+		}
+		if err != nil {
+			policies.rejected[r.Name] = err
+			continue
+		}
+		policies.items = append(policies.items, p)
+	}
+
+	for _, p := range items {
+		p, err := complete(p, defaultPolicy)
+		if err != nil {
+			return policies, err
+		}
+		policies.items = append(policies.items, p)
+	}
+
+	return policies, nil
+}
+
+//complete validates the policy and fills in the values it does not set from the default policy.
+func complete(p Policy, defaultPolicy Policy) (Policy, error) {
+	selector, err := labels.Parse(p.LabelSelector)
+	if err != nil {
+		return p, fmt.Errorf("policy %s has an invalid label selector: %s", p.Name, err.Error())
+	}
+	p.selector = selector
+	p.nodePoolLabel = defaultPolicy.nodePoolLabel
+
+	// The day intervals of the Spotter only apply with its intervals, a policy with its own window keeps it every day.
+	if p.WhiteListIntervalHours == "" && p.WhiteListDayIntervalHours == nil {
+		p.WhiteListDayIntervalHours = defaultPolicy.WhiteListDayIntervalHours
+	}
+	if p.WhiteListIntervalHours == "" {
+		p.WhiteListIntervalHours = defaultPolicy.WhiteListIntervalHours
+	}
+	p.dayIntervals, err = calendar.ParseDayIntervals(p.WhiteListDayIntervalHours)
+	if err != nil {
+		return p, fmt.Errorf("policy %s has invalid day intervals: %s", p.Name, err.Error())
+	}
+	if p.WhiteListTimezone == "" {
+		p.WhiteListTimezone = defaultPolicy.WhiteListTimezone
+	}
+	p.location, err = time.LoadLocation(p.WhiteListTimezone)
+	if err != nil {
+		return p, fmt.Errorf("policy %s has an invalid timezone: %s", p.Name, err.Error())
+	}
+	if err := config.ValidateIntervals(withDayIntervals(p.WhiteListIntervals(), p.dayIntervals)); err != nil {
+		return p, fmt.Errorf("policy %s has an invalid whitelist: %s", p.Name, err.Error())
+	}
+
+	p.shiftDayIntervals, err = calendar.ParseDayIntervals(p.ShiftWhiteListDayIntervalHours)
+	if err != nil {
+		return p, fmt.Errorf("policy %s has invalid shift day intervals: %s", p.Name, err.Error())
+	}
+	if p.ShiftWhiteListTimezone != "" {
+		p.shiftLocation, err = time.LoadLocation(p.ShiftWhiteListTimezone)
+		if err != nil {
+			return p, fmt.Errorf("policy %s has an invalid shift timezone: %s", p.Name, err.Error())
+		}
+	}
+	if p.HasShiftWhiteList() {
+		if err := config.ValidateIntervals(withDayIntervals(strings.Split(p.ShiftWhiteListIntervalHours, config.CommaSeparater), p.shiftDayIntervals)); err != nil {
+			return p, fmt.Errorf("policy %s has an invalid shift whitelist: %s", p.Name, err.Error())
+		}
+	}
+
+	if p.DrainingTimeoutMs == 0 {
+		p.DrainingTimeoutMs = defaultPolicy.DrainingTimeoutMs
+	}
+	if p.Concurrency <= 0 {
+		p.Concurrency = defaultPolicy.Concurrency
+	}
+	return p, nil
+}
+
+//withDayIntervals returns the intervals followed by the intervals of each day.
+func withDayIntervals(intervals []string, dayIntervals map[time.Weekday][]string) []string {
+	all := append([]string{}, intervals...)
+	for _, day := range dayIntervals {
+		all = append(all, day...)
+	}
+	return all
+}
+
+//Matches returns true if the node is in one of the nodepools of the policy, read from the
+//prometheus_metrics.nodepool_label label, and its labels match the label selector of the policy.
+func (p Policy) Matches(node v1.Node) bool {
+	if len(p.NodePools) > 0 {
+		label := p.nodePoolLabel
+		if label == "" {
+			label = config.NodePoolNameLabel
+		}
+		nodePool := node.Labels[label]
+		found := false
+		for _, np := range p.NodePools {
+			if np == nodePool {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return p.selector == nil || p.selector.Matches(labels.Set(node.Labels))
+}
+
+//WhiteListIntervals returns the whitelist intervals of the policy in HH:MM-HH:MM format.
+func (p Policy) WhiteListIntervals() []string {
+	return strings.Split(p.WhiteListIntervalHours, config.CommaSeparater)
+}
+
+//WhiteListIntervalsOn returns the whitelist intervals of the policy on the day of the week in HH:MM-HH:MM format.
+func (p Policy) WhiteListIntervalsOn(day time.Weekday) []string {
+	if intervals, ok := p.dayIntervals[day]; ok {
+		return intervals
+	}
+	return p.WhiteListIntervals()
+}
+
+//HasShiftWhiteList returns true if the policy replaces the whitelist of the Shifter for its nodes.
+func (p Policy) HasShiftWhiteList() bool {
+	return p.ShiftWhiteListIntervalHours != "" || len(p.ShiftWhiteListDayIntervalHours) > 0
+}
+
+//ShiftWhiteListIntervalsOn returns the shift whitelist intervals of the policy on the day of the week in HH:MM-HH:MM format.
+func (p Policy) ShiftWhiteListIntervalsOn(day time.Weekday) []string {
+	if intervals, ok := p.shiftDayIntervals[day]; ok {
+		return intervals
+	}
+	if p.ShiftWhiteListIntervalHours == "" {
+		return []string{}
+	}
+	return strings.Split(p.ShiftWhiteListIntervalHours, config.CommaSeparater)
+}
+
+//ShiftLocation returns the timezone in which the shift whitelist intervals of the policy are read,
+//nil when the timezone of the Shifter applies.
+func (p Policy) ShiftLocation() *time.Location {
+	return p.shiftLocation
+}
+
+//Location returns the timezone in which the whitelist intervals of the policy are read, UTC by default.
+func (p Policy) Location() *time.Location {
+	if p.location == nil {
+		return time.UTC
+	}
+	return p.location
+}
+
+//ForNode returns the first policy that matches the node, or the default policy if none matches.
+func (ps Policies) ForNode(node v1.Node) Policy {
+	for _, p := range ps.items {
+		if p.Matches(node) {
+			return p
+		}
+	}
+	return ps.defaultPolicy
+}
+
+//ForAnnotatedNode returns the policy recorded in the policy annotation of the node,
+//or the policy matching the node if the annotation is missing or names an unknown policy.
+func (ps Policies) ForAnnotatedNode(node v1.Node) Policy {
+	if p, ok := ps.Get(node.Annotations[config.PolicyAnnotation]); ok {
+		return p
+	}
+	return ps.ForNode(node)
+}
+
+//Get returns the policy with the given name.
+func (ps Policies) Get(name string) (Policy, bool) {
+	if name == DefaultPolicyName {
+		return ps.defaultPolicy, true
+	}
+	for _, p := range ps.items {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Policy{}, false
+}
+
+//Rejected returns the policy resources which were left out, with the reason.
+func (ps Policies) Rejected() map[string]error {
+	return ps.rejected
+}
+
+//All returns the configured policies followed by the default policy.
+func (ps Policies) All() []Policy {
+	return append(append([]Policy{}, ps.items...), ps.defaultPolicy)
+}
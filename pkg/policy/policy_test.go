@@ -0,0 +1,231 @@
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const policiesConfig = `
+SPOTTER:
+  WHITE_LIST_INTERVAL_HOURS: 00:00-06:00
+  WHITE_LIST_TIMEZONE: Asia/Kolkata
+KILLER:
+  DRAINING_TIMEOUT_WHEN_NODE_EXPIRED_MS: 300000
+POLICIES:
+  - NAME: ingress
+    NODEPOOLS: [ingress-p-1, ingress-p-2]
+    WHITE_LIST_INTERVAL_HOURS: 02:00-04:00,14:00-15:00
+    WHITE_LIST_TIMEZONE: Europe/Berlin
+    WHITE_LIST_DAY_INTERVAL_HOURS:
+      SATURDAY: 00:00-08:00
+    CONCURRENCY: 2
+  - NAME: batch
+    LABEL_SELECTOR: component=batch
+    DRAINING_TIMEOUT_MS: 60000
+`
+
+type PolicyTestSuite struct {
+	suite.Suite
+	configFile string
+}
+
+func (pt *PolicyTestSuite) SetupTest() {
+	pt.configFile = writeConfig(pt.T(), policiesConfig)
+}
+
+func (pt *PolicyTestSuite) TearDownTest() {
+	os.Remove(pt.configFile)
+}
+
+func writeConfig(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "application-*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(content)
+	f.Close()
+	return f.Name()
+}
+
+func node(name string, labels map[string]string) v1.Node {
+	return v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func (pt *PolicyTestSuite) TestShouldLoadPoliciesWithDefaults() {
+	policies, err := Load(config.Init(pt.configFile), nil)
+	assert.Nil(pt.T(), err)
+
+	ingress, ok := policies.Get("ingress")
+	assert.True(pt.T(), ok)
+	assert.Equal(pt.T(), []string{"02:00-04:00", "14:00-15:00"}, ingress.WhiteListIntervals())
+	assert.Equal(pt.T(), uint32(300000), ingress.DrainingTimeoutMs)
+	assert.Equal(pt.T(), 2, ingress.Concurrency)
+	assert.Equal(pt.T(), "Europe/Berlin", ingress.Location().String())
+	assert.Equal(pt.T(), []string{"00:00-08:00"}, ingress.WhiteListIntervalsOn(time.Saturday))
+	assert.Equal(pt.T(), []string{"02:00-04:00", "14:00-15:00"}, ingress.WhiteListIntervalsOn(time.Sunday))
+
+	batch, ok := policies.Get("batch")
+	assert.True(pt.T(), ok)
+	assert.Equal(pt.T(), []string{"00:00-06:00"}, batch.WhiteListIntervals())
+	assert.Equal(pt.T(), uint32(60000), batch.DrainingTimeoutMs)
+	assert.Equal(pt.T(), 1, batch.Concurrency)
+	assert.Equal(pt.T(), "Asia/Kolkata", batch.Location().String())
+
+	assert.Equal(pt.T(), 3, len(policies.All()))
+}
+
+func (pt *PolicyTestSuite) TestShouldOnlyInheritDayIntervalsWithoutOwnIntervals() {
+	configFile := writeConfig(pt.T(), `
+SPOTTER:
+  WHITE_LIST_INTERVAL_HOURS: 00:00-06:00
+  WHITE_LIST_DAY_INTERVAL_HOURS:
+    SATURDAY: 00:00-08:00,13:00-17:00
+POLICIES:
+  - NAME: ingress
+    NODEPOOLS: [ingress-p-1]
+    WHITE_LIST_INTERVAL_HOURS: 02:00-04:00
+  - NAME: batch
+    LABEL_SELECTOR: component=batch
+`)
+	defer os.Remove(configFile)
+
+	policies, err := Load(config.Init(configFile), nil)
+	assert.Nil(pt.T(), err)
+
+	ingress, _ := policies.Get("ingress")
+	assert.Equal(pt.T(), []string{"02:00-04:00"}, ingress.WhiteListIntervalsOn(time.Saturday))
+	batch, _ := policies.Get("batch")
+	assert.Equal(pt.T(), []string{"00:00-08:00", "13:00-17:00"}, batch.WhiteListIntervalsOn(time.Saturday))
+	assert.Equal(pt.T(), []string{"00:00-06:00"}, batch.WhiteListIntervalsOn(time.Sunday))
+}
+
+func (pt *PolicyTestSuite) TestShouldChoosePolicyForNode() {
+	policies, err := Load(config.Init(pt.configFile), nil)
+	assert.Nil(pt.T(), err)
+
+	assert.Equal(pt.T(), "ingress", policies.ForNode(node("node-1", map[string]string{config.NodePoolNameLabel: "ingress-p-2"})).Name)
+	assert.Equal(pt.T(), "batch", policies.ForNode(node("node-2", map[string]string{"component": "batch"})).Name)
+	assert.Equal(pt.T(), DefaultPolicyName, policies.ForNode(node("node-3", map[string]string{"component": "services"})).Name)
+}
+
+func (pt *PolicyTestSuite) TestShouldMatchNodePoolsWithConfiguredLabel() {
+	configFile := writeConfig(pt.T(), policiesConfig+"PROMETHEUS_METRICS:\n  NODEPOOL_LABEL: example.com/pool\n")
+	defer os.Remove(configFile)
+
+	policies, err := Load(config.Init(configFile), nil)
+	assert.Nil(pt.T(), err)
+	assert.Equal(pt.T(), "ingress", policies.ForNode(node("node-1", map[string]string{"example.com/pool": "ingress-p-1"})).Name)
+	assert.Equal(pt.T(), DefaultPolicyName, policies.ForNode(node("node-2", map[string]string{config.NodePoolNameLabel: "ingress-p-1"})).Name)
+}
+
+func (pt *PolicyTestSuite) TestShouldPreferAnnotatedPolicy() {
+	policies, err := Load(config.Init(pt.configFile), nil)
+	assert.Nil(pt.T(), err)
+
+	annotated := node("node-1", map[string]string{"component": "batch"})
+	annotated.Annotations = map[string]string{config.PolicyAnnotation: "ingress"}
+	assert.Equal(pt.T(), "ingress", policies.ForAnnotatedNode(annotated).Name)
+
+	annotated.Annotations[config.PolicyAnnotation] = "removed"
+	assert.Equal(pt.T(), "batch", policies.ForAnnotatedNode(annotated).Name)
+}
+
+func (pt *PolicyTestSuite) TestShouldRejectDuplicatePolicyNames() {
+	configFile := writeConfig(pt.T(), "POLICIES:\n  - NAME: default\n")
+	defer os.Remove(configFile)
+
+	_, err := Load(config.Init(configFile), nil)
+	assert.NotNil(pt.T(), err)
+}
+
+func (pt *PolicyTestSuite) TestShouldRejectInvalidTimezone() {
+	configFile := writeConfig(pt.T(), "POLICIES:\n  - NAME: ingress\n    WHITE_LIST_TIMEZONE: Mars/Olympus\n")
+	defer os.Remove(configFile)
+
+	_, err := Load(config.Init(configFile), nil)
+	assert.NotNil(pt.T(), err)
+}
+
+func (pt *PolicyTestSuite) TestShouldRejectInvalidShiftWhitelistOverriddenOnSunday() {
+	configFile := writeConfig(pt.T(), "POLICIES:\n  - NAME: ingress\n    SHIFT_WHITE_LIST_INTERVAL_HOURS: 3-4\n    SHIFT_WHITE_LIST_DAY_INTERVAL_HOURS:\n      sunday: 03:00-04:00\n")
+	defer os.Remove(configFile)
+
+	_, err := Load(config.Init(configFile), nil)
+	assert.NotNil(pt.T(), err)
+	assert.Contains(pt.T(), err.Error(), "invalid shift whitelist")
+}
+
+type resources []SilentAssassinPolicy
+
+func (rs resources) ListResources() []SilentAssassinPolicy {
+	return rs
+}
+
+func resource(name string, priority int, spec SilentAssassinPolicySpec) SilentAssassinPolicy {
+	spec.Priority = priority
+	return SilentAssassinPolicy{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+}
+
+func (pt *PolicyTestSuite) TestShouldMatchResourcesBeforeConfiguredPolicies() {
+	src := resources{
+		resource("ingress-night", 0, SilentAssassinPolicySpec{
+			NodePools:     []string{"ingress-p-1"},
+			KillWindow:    Window{IntervalHours: "01:00-02:00"},
+			ShiftWindow:   Window{IntervalHours: "03:00-04:00", Timezone: "Europe/Berlin"},
+			Notifications: Notifications{SlackChannel: "ingress-alerts"},
+		}),
+		resource("batch-critical", 10, SilentAssassinPolicySpec{
+			NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"component": "batch", "criticality": "1"}},
+		}),
+	}
+
+	policies, err := Load(config.Init(pt.configFile), src)
+	assert.Nil(pt.T(), err)
+	assert.Empty(pt.T(), policies.Rejected())
+
+	ingress := policies.ForNode(node("node-1", map[string]string{config.NodePoolNameLabel: "ingress-p-1"}))
+	assert.Equal(pt.T(), "ingress-night", ingress.Name)
+	assert.Equal(pt.T(), []string{"01:00-02:00"}, ingress.WhiteListIntervals())
+	assert.Equal(pt.T(), "Asia/Kolkata", ingress.Location().String())
+	assert.Equal(pt.T(), uint32(300000), ingress.DrainingTimeoutMs)
+	assert.True(pt.T(), ingress.HasShiftWhiteList())
+	assert.Equal(pt.T(), []string{"03:00-04:00"}, ingress.ShiftWhiteListIntervalsOn(time.Monday))
+	assert.Equal(pt.T(), "Europe/Berlin", ingress.ShiftLocation().String())
+	assert.Equal(pt.T(), "ingress-alerts", ingress.SlackChannel)
+
+	assert.Equal(pt.T(), "batch-critical", policies.ForNode(node("node-2", map[string]string{"component": "batch", "criticality": "1"})).Name)
+	assert.Equal(pt.T(), "batch", policies.ForNode(node("node-3", map[string]string{"component": "batch"})).Name)
+	assert.False(pt.T(), policies.ForNode(node("node-3", map[string]string{"component": "batch"})).HasShiftWhiteList())
+	assert.Equal(pt.T(), 5, len(policies.All()))
+}
+
+func (pt *PolicyTestSuite) TestShouldRejectInvalidResources() {
+	src := resources{
+		resource("batch", 0, SilentAssassinPolicySpec{}),
+		resource("mars", 0, SilentAssassinPolicySpec{KillWindow: Window{Timezone: "Mars/Olympus"}}),
+		resource("typo", 0, SilentAssassinPolicySpec{ShiftWindow: Window{IntervalHours: "3-4"}}),
+		resource("valid", 0, SilentAssassinPolicySpec{NodePools: []string{"services-p-1"}}),
+	}
+
+	policies, err := Load(config.Init(pt.configFile), src)
+	assert.Nil(pt.T(), err)
+
+	assert.Equal(pt.T(), 3, len(policies.Rejected()))
+	assert.Contains(pt.T(), policies.Rejected()["batch"].Error(), "not unique")
+	assert.Contains(pt.T(), policies.Rejected()["mars"].Error(), "invalid timezone")
+	assert.Contains(pt.T(), policies.Rejected()["typo"].Error(), "invalid shift whitelist")
+	_, ok := policies.Get("valid")
+	assert.True(pt.T(), ok)
+}
+
+func TestPolicyTestSuite(t *testing.T) {
+	suite.Run(t, new(PolicyTestSuite))
+}
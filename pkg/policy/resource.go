@@ -0,0 +1,90 @@
+package policy
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+//GroupVersionResource identifies the cluster scoped SilentAssassinPolicy custom resource.
+var GroupVersionResource = schema.GroupVersionResource{
+	Group:    "silent-assassin.roppenlabs.com",
+	Version:  "v1alpha1",
+	Resource: "silentassassinpolicies",
+}
+
+//SilentAssassinPolicy declares a policy as a custom resource, instead of in the configuration file.
+type SilentAssassinPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SilentAssassinPolicySpec   `json:"spec"`
+	Status SilentAssassinPolicyStatus `json:"status,omitempty"`
+}
+
+//SilentAssassinPolicySpec sets the nodes the policy matches and how they are killed and shifted.
+//Policies with a higher Priority are matched first, policies of the same Priority by name.
+type SilentAssassinPolicySpec struct {
+	Priority       int                   `json:"priority,omitempty"`
+	NodePools      []string              `json:"nodePools,omitempty"`
+	NodeSelector   *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	KillWindow     Window                `json:"killWindow,omitempty"`
+	ShiftWindow    Window                `json:"shiftWindow,omitempty"`
+	DrainTimeoutMs uint32                `json:"drainTimeoutMs,omitempty"`
+	Concurrency    int                   `json:"concurrency,omitempty"`
+	Notifications  Notifications         `json:"notifications,omitempty"`
+}
+
+//Window holds whitelist intervals in HH:MM-HH:MM format separated by commas, the intervals replacing them
+//on some days of the week and the timezone in which they are read.
+type Window struct {
+	IntervalHours    string            `json:"intervalHours,omitempty"`
+	DayIntervalHours map[string]string `json:"dayIntervalHours,omitempty"`
+	Timezone         string            `json:"timezone,omitempty"`
+}
+
+//Notifications routes the notifications about the nodes of the policy.
+type Notifications struct {
+	SlackChannel string `json:"slackChannel,omitempty"`
+}
+
+//SilentAssassinPolicyStatus reports whether the spec was accepted and the nodes the policy covers,
+//the nodes it is the first policy to match.
+type SilentAssassinPolicyStatus struct {
+	ObservedGeneration int64    `json:"observedGeneration,omitempty"`
+	Valid              bool     `json:"valid"`
+	Message            string   `json:"message,omitempty"`
+	NodeCount          int      `json:"nodeCount"`
+	Nodes              []string `json:"nodes,omitempty"`
+}
+
+//ISource lists the SilentAssassinPolicy resources of the cluster.
+type ISource interface {
+	ListResources() []SilentAssassinPolicy
+}
+
+//fromResource converts the spec of the resource to a policy named after the resource.
+func fromResource(r SilentAssassinPolicy) (Policy, error) {
+	p := Policy{
+		Name:                           r.Name,
+		NodePools:                      r.Spec.NodePools,
+		WhiteListIntervalHours:         r.Spec.KillWindow.IntervalHours,
+		WhiteListDayIntervalHours:      r.Spec.KillWindow.DayIntervalHours,
+		WhiteListTimezone:              r.Spec.KillWindow.Timezone,
+		ShiftWhiteListIntervalHours:    r.Spec.ShiftWindow.IntervalHours,
+		ShiftWhiteListDayIntervalHours: r.Spec.ShiftWindow.DayIntervalHours,
+		ShiftWhiteListTimezone:         r.Spec.ShiftWindow.Timezone,
+		DrainingTimeoutMs:              r.Spec.DrainTimeoutMs,
+		Concurrency:                    r.Spec.Concurrency,
+		SlackChannel:                   r.Spec.Notifications.SlackChannel,
+	}
+	if r.Spec.NodeSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(r.Spec.NodeSelector)
+		if err != nil {
+			return p, fmt.Errorf("policy %s has an invalid node selector: %s", r.Name, err.Error())
+		}
+		p.LabelSelector = selector.String()
+	}
+	return p, nil
+}
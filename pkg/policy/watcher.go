@@ -0,0 +1,183 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+//watcherResyncPeriod is the period after which the informer replays the cached policies to the handlers.
+const watcherResyncPeriod = 10 * time.Minute
+
+//Watcher keeps the SilentAssassinPolicy resources in an informer cache, so every load of the policies
+//sees their latest version, and reports in the status of each resource the nodes it covers.
+type Watcher struct {
+	cp         config.IProvider
+	logger     logger.IZapLogger
+	kubeClient k8s.IKubernetesClient
+	client     dynamic.NamespaceableResourceInterface
+	informer   cache.SharedIndexInformer
+	notifier   notifier.INotifierClient
+}
+
+func NewWatcher(cp config.IProvider, zl logger.IZapLogger, kc k8s.IKubernetesClient, dc dynamic.Interface, nf notifier.INotifierClient) *Watcher {
+	informer := dynamicinformer.NewFilteredDynamicInformer(dc, GroupVersionResource, "", watcherResyncPeriod, cache.Indexers{}, nil).Informer()
+	w := &Watcher{
+		cp:         cp,
+		logger:     zl,
+		kubeClient: kc,
+		client:     dc.Resource(GroupVersionResource),
+		informer:   informer,
+		notifier:   nf,
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.logEvent("added", obj) },
+		UpdateFunc: func(oldObj, newObj interface{}) { w.logEvent("updated", newObj) },
+		DeleteFunc: func(obj interface{}) { w.logEvent("deleted", obj) },
+	})
+	return w
+}
+
+func (w *Watcher) logEvent(event string, obj interface{}) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		w.logger.Debug(fmt.Sprintf("Policy %s %s", u.GetName(), event))
+	}
+}
+
+//Sync starts the informer and blocks until its cache is synced.
+//The informer is stopped when the context is cancelled.
+func (w *Watcher) Sync(ctx context.Context) error {
+	w.logger.Info("Starting policy informer")
+	go w.informer.Run(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), w.informer.HasSynced) {
+		return errors.New("timed out waiting for policy cache to sync")
+	}
+	w.logger.Info("Policy cache synced")
+	return nil
+}
+
+//ListResources returns the cached policy resources. A resource which cannot be read is left out.
+func (w *Watcher) ListResources() []SilentAssassinPolicy {
+	resources := []SilentAssassinPolicy{}
+	for _, obj := range w.informer.GetStore().List() {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		var r SilentAssassinPolicy
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &r); err != nil {
+			w.logger.Warn(fmt.Sprintf("Error reading policy %s: %s", u.GetName(), err.Error()))
+			continue
+		}
+		resources = append(resources, r)
+	}
+	return resources
+}
+
+//Start updates the status of the policy resources at every status interval. Only the leader runs it,
+//the informer cache is synced by every replica.
+func (w *Watcher) Start(ctx context.Context, wg *sync.WaitGroup) {
+	w.logger.Info(fmt.Sprintf("Starting Policy status Loop - Interval : %d", w.cp.GetInt(config.PolicyCRDStatusIntervalMs)))
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Shutting down policy status service")
+			wg.Done()
+			return
+		case <-time.After(time.Millisecond * time.Duration(w.cp.GetInt(config.PolicyCRDStatusIntervalMs))):
+			w.updateStatuses()
+		}
+	}
+}
+
+//updateStatuses sets the status of every policy resource to whether it was accepted and the managed nodes
+//it applies to, the ones annotated with it or which it is the first policy to match, as the Killer applies it.
+//Statuses which did not change are not written.
+func (w *Watcher) updateStatuses() {
+	resources := w.ListResources()
+	if len(resources) == 0 {
+		return
+	}
+
+	policies, err := Load(w.cp, w)
+	if err != nil {
+		w.logger.Error(fmt.Sprintf("Error loading policies %s", err.Error()))
+		w.notifier.Error(config.EventPolicy, fmt.Sprintf("Error loading policies %s", err.Error()))
+		return
+	}
+
+	nodes, err := w.kubeClient.GetNodes(w.cp.GetString(config.NodeSelectors))
+	if err != nil {
+		w.logger.Error(fmt.Sprintf("Error getting nodes %s", err.Error()))
+		return
+	}
+	covered := make(map[string][]string)
+	for _, node := range nodes.Items {
+		name := policies.ForAnnotatedNode(node).Name
+		covered[name] = append(covered[name], node.Name)
+	}
+
+	for _, r := range resources {
+		status := SilentAssassinPolicyStatus{ObservedGeneration: r.Generation, Valid: true}
+		if err, ok := policies.Rejected()[r.Name]; ok {
+			status.Valid = false
+			status.Message = err.Error()
+		} else {
+			status.Nodes = covered[r.Name]
+			sort.Strings(status.Nodes)
+			status.NodeCount = len(status.Nodes)
+		}
+		if reflect.DeepEqual(status, r.Status) {
+			continue
+		}
+
+		if err := w.updateStatus(r.Name, status); err != nil {
+			w.logger.Error(fmt.Sprintf("Error updating the status of policy %s: %s", r.Name, err.Error()))
+			continue
+		}
+		if !status.Valid && r.Status.Message != status.Message {
+			w.logger.Error(fmt.Sprintf("Policy %s is not valid: %s", r.Name, status.Message))
+			w.notifier.Error(config.EventPolicy, fmt.Sprintf("Policy %s is not valid: %s", r.Name, status.Message))
+		}
+	}
+}
+
+func (w *Watcher) updateStatus(name string, status SilentAssassinPolicyStatus) error {
+	obj, exists, err := w.informer.GetStore().GetByKey(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("policy %s not found", name)
+	}
+
+	u := obj.(*unstructured.Unstructured).DeepCopy()
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(u.Object, content, "status"); err != nil {
+		return err
+	}
+
+	_, err = w.client.UpdateStatus(u, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"context"
+	"os"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	"github.com/roppenlabs/silent-assassin/pkg/logger"
+	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+//fakeDynamicClient serves the policy resources it holds to the informer and stores their status updates.
+type fakeDynamicClient struct {
+	dynamic.NamespaceableResourceInterface
+	objects map[string]*unstructured.Unstructured
+}
+
+func newFakeDynamicClient(objects ...*unstructured.Unstructured) *fakeDynamicClient {
+	c := &fakeDynamicClient{objects: make(map[string]*unstructured.Unstructured)}
+	for _, obj := range objects {
+		c.objects[obj.GetName()] = obj
+	}
+	return c
+}
+
+func (c *fakeDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return c
+}
+
+func (c *fakeDynamicClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return c
+}
+
+func (c *fakeDynamicClient) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	for _, obj := range c.objects {
+		list.Items = append(list.Items, *obj.DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *fakeDynamicClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return watch.NewFake(), nil
+}
+
+func (c *fakeDynamicClient) Get(name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	return c.objects[name].DeepCopy(), nil
+}
+
+func (c *fakeDynamicClient) UpdateStatus(obj *unstructured.Unstructured, opts metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	c.objects[obj.GetName()] = obj.DeepCopy()
+	return obj, nil
+}
+
+func unstructuredResource(r SilentAssassinPolicy) *unstructured.Unstructured {
+	r.APIVersion = GroupVersionResource.GroupVersion().String()
+	r.Kind = "SilentAssassinPolicy"
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&r)
+	if err != nil {
+		panic(err)
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func (pt *PolicyTestSuite) TestShouldReportCoveredNodesInStatus() {
+	configFile := writeConfig(pt.T(), "SPOTTER:\n  WHITE_LIST_INTERVAL_HOURS: 00:00-06:00\nLOGGER:\n  LEVEL: info\n")
+	defer os.Remove(configFile)
+	cp := config.Init(configFile)
+
+	ingress := resource("ingress", 0, SilentAssassinPolicySpec{NodePools: []string{"ingress-p-1"}})
+	ingress.Generation = 2
+	broken := resource("broken", 0, SilentAssassinPolicySpec{KillWindow: Window{Timezone: "Mars/Olympus"}})
+	dynamicClient := newFakeDynamicClient(unstructuredResource(ingress), unstructuredResource(broken))
+
+	k8sMock := new(k8s.K8sClientMock)
+	// Only the managed nodes are listed, and the policy annotated by the Spotter wins over the matching one.
+	annotated := node("node-4", map[string]string{config.NodePoolNameLabel: "services-p-1"})
+	annotated.Annotations = map[string]string{config.PolicyAnnotation: "ingress"}
+	k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true").Return(&v1.NodeList{Items: []v1.Node{
+		node("node-2", map[string]string{config.NodePoolNameLabel: "ingress-p-1"}),
+		node("node-1", map[string]string{config.NodePoolNameLabel: "ingress-p-1"}),
+		node("node-3", map[string]string{config.NodePoolNameLabel: "services-p-1"}),
+		annotated,
+	}}, nil)
+	notifierMock := new(notifier.NotifierClientMock)
+	notifierMock.On("Error", config.EventPolicy, mock.Anything)
+
+	w := NewWatcher(cp, logger.Init(cp), k8sMock, dynamicClient, notifierMock)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Nil(pt.T(), w.Sync(ctx))
+	assert.Equal(pt.T(), 2, len(w.ListResources()))
+
+	w.updateStatuses()
+
+	status := func(name string) SilentAssassinPolicyStatus {
+		u, err := dynamicClient.Resource(GroupVersionResource).Get(name, metav1.GetOptions{})
+		assert.Nil(pt.T(), err)
+		var r SilentAssassinPolicy
+		assert.Nil(pt.T(), runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &r))
+		return r.Status
+	}
+
+	assert.Equal(pt.T(), SilentAssassinPolicyStatus{ObservedGeneration: 2, Valid: true, NodeCount: 3, Nodes: []string{"node-1", "node-2", "node-4"}}, status("ingress"))
+	assert.False(pt.T(), status("broken").Valid)
+	assert.Contains(pt.T(), status("broken").Message, "invalid timezone")
+}
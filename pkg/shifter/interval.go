@@ -5,7 +5,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/roppenlabs/silent-assassin/pkg/calendar"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
 )
 
 type wlInterval struct {
@@ -13,29 +15,138 @@ type wlInterval struct {
 	end   time.Time
 }
 
-//initWhitelist reads the ShifterWhiteListIntervalHours from the configuration and sets whiteListIntervals
-// in the shifter struct.
+//initWhitelist reads the ShifterWhiteListIntervalHours, ShifterWhiteListDayIntervalHours and ShifterWhiteListTimezone
+//from the configuration and sets whiteListIntervals of each day of the week and location in the shifter struct.
+//It panics if the whitelist is invalid.
 func (ss *ShifterService) initWhitelist() {
+	if err := ss.loadWhitelist(); err != nil {
+		ss.logger.Error(fmt.Sprintf("Shifter: Error loading WhiteList Reason: %v", err))
+		panic(err)
+	}
+}
+
+//loadWhitelist reads the whitelist from the configuration and sets it in the shifter struct.
+func (ss *ShifterService) loadWhitelist() error {
+	location, whiteListIntervals, err := readWhitelist(ss.cp)
+	if err != nil {
+		return err
+	}
+	ss.location = location
+	ss.whiteListIntervals = whiteListIntervals
+	ss.logger.Info(fmt.Sprintf("Shifter: Whitelist set initialized : %v %v", ss.whiteListIntervals, ss.location))
+	return nil
+}
+
+//readWhitelist reads the timezone and the whitelist intervals of each day of the week from the configuration.
+func readWhitelist(cp config.IProvider) (*time.Location, [7][]wlInterval, error) {
+	var whiteListIntervals [7][]wlInterval
+
+	location, err := time.LoadLocation(cp.GetString(config.ShifterWhiteListTimezone))
+	if err != nil {
+		return nil, whiteListIntervals, fmt.Errorf("invalid %s: %v", config.ShifterWhiteListTimezone, err)
+	}
+
+	dayIntervals, err := calendar.ParseDayIntervals(cp.GetStringMapString(config.ShifterWhiteListDayIntervalHours))
+	if err != nil {
+		return nil, whiteListIntervals, fmt.Errorf("invalid %s: %v", config.ShifterWhiteListDayIntervalHours, err)
+	}
 
-	ss.whiteListIntervals = []wlInterval{}
-	wlStr := ss.cp.SplitStringToSlice(config.ShifterWhiteListIntervalHours, config.CommaSeparater)
-	ss.logger.Info(fmt.Sprintf("Shifter: Whitelist intervals: %v", wlStr))
+	wlStr := cp.SplitStringToSlice(config.ShifterWhiteListIntervalHours, config.CommaSeparater)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		dayWlStr, ok := dayIntervals[day]
+		if !ok {
+			dayWlStr = wlStr
+		}
+		whiteListIntervals[day], err = parseIntervals(dayWlStr)
+		if err != nil {
+			return nil, whiteListIntervals, fmt.Errorf("invalid whitelist on %s: %v", day, err)
+		}
+	}
+	return location, whiteListIntervals, nil
+}
+
+//parseWhitelist parses the intervals in HH:MM-HH:MM format, it panics if any is invalid.
+func (ss *ShifterService) parseWhitelist(wlStr []string) []wlInterval {
+	intervals, err := parseIntervals(wlStr)
+	if err != nil {
+		ss.logger.Error(fmt.Sprintf("Shifter: Error parsing WhiteList Reason: %v", err))
+		panic(err)
+	}
+	return intervals
+}
+
+//parseIntervals parses the intervals in HH:MM-HH:MM format.
+func parseIntervals(wlStr []string) ([]wlInterval, error) {
+	intervals := []wlInterval{}
 	for _, wl := range wlStr {
 		times := strings.Split(wl, "-")
+		if len(times) != 2 {
+			return intervals, fmt.Errorf("invalid interval %s, expected HH:MM-HH:MM", wl)
+		}
 		start, err := time.Parse(timeLayout, times[0])
 		if err != nil {
-			ss.logger.Error(fmt.Sprintf("Shifter: Error parsing WhiteList Start time Reason: %v", err))
-			panic(err)
+			return intervals, err
 		}
 
 		end, err := time.Parse(timeLayout, times[1])
 		if err != nil {
-			ss.logger.Error(fmt.Sprintf("Shifter: Error parsing WhiteList end time Reason: %v", err))
-			panic(err)
+			return intervals, err
+		}
+		intervals = append(intervals, wlInterval{start, end})
+	}
+	return intervals, nil
+}
+
+//withinWhitelist returns true if the wall clock time of t, in the timezone of the whitelist,
+//is in one of the whiteListIntervals of its day of the week.
+func (ss ShifterService) withinWhitelist(t time.Time) bool {
+	t = t.In(ss.location)
+	now, err := time.Parse(timeLayout, fmt.Sprintf("%02d:%02d", t.Hour(), t.Minute()))
+	if err != nil {
+		panic(err)
+	}
+
+	for _, interval := range ss.whiteListIntervals[t.Weekday()] {
+		if timeWithinWLIntervalCheck(interval.start, interval.end, now) {
+			return true
+		}
+	}
+	return false
+}
+
+//withinShiftWhiteList returns true if the wall clock time of t is in one of the shift whitelist intervals of the policy,
+//read in the timezone of the policy or of the Shifter, or within the whitelist of the Shifter when the policy has none.
+func (ss ShifterService) withinShiftWhiteList(p policy.Policy, t time.Time) bool {
+	if !p.HasShiftWhiteList() {
+		return ss.withinWhitelist(t)
+	}
+
+	location := p.ShiftLocation()
+	if location == nil {
+		location = ss.location
+	}
+	t = t.In(location)
+	now, err := time.Parse(timeLayout, fmt.Sprintf("%02d:%02d", t.Hour(), t.Minute()))
+	if err != nil {
+		panic(err)
+	}
+
+	for _, interval := range ss.parseWhitelist(p.ShiftWhiteListIntervalsOn(t.Weekday())) {
+		if timeWithinWLIntervalCheck(interval.start, interval.end, now) {
+			return true
+		}
+	}
+	return false
+}
+
+//withinAnyShiftWhiteList returns true if t is within the shift whitelist of one of the policies.
+func (ss ShifterService) withinAnyShiftWhiteList(policies policy.Policies, t time.Time) bool {
+	for _, p := range policies.All() {
+		if p.HasShiftWhiteList() && ss.withinShiftWhiteList(p, t) {
+			return true
 		}
-		ss.whiteListIntervals = append(ss.whiteListIntervals, wlInterval{start, end})
 	}
-	ss.logger.Info(fmt.Sprintf("Shifter: Whitelist set initialized : %v", ss.whiteListIntervals))
+	return false
 }
 
 // timeWithinWLIntervalCheck accepts 'start', 'end', 'check' times
@@ -1,9 +1,13 @@
 package shifter
 
 import (
+	"io/ioutil"
+	"os"
 	"strconv"
 	"time"
 
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,3 +32,60 @@ func (st *ShifterTestSuit) TestIfTimeIsWithinWLInterval() {
 	}
 
 }
+
+func (st *ShifterTestSuit) TestIfTimeIsWithinWLIntervalOfTimezone() {
+	location, _ := time.LoadLocation("Asia/Kolkata")
+	start, _ := time.Parse(timeLayout, "02:00")
+	end, _ := time.Parse(timeLayout, "03:00")
+	ss := ShifterService{location: location}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		ss.whiteListIntervals[day] = []wlInterval{{start, end}}
+	}
+
+	within, _ := time.Parse(time.RFC3339, "2020-06-22T20:45:00Z")
+	outside, _ := time.Parse(time.RFC3339, "2020-06-22T02:30:00Z")
+
+	assert.True(st.T(), ss.withinWhitelist(within), "02:15 IST should be within 02:00-03:00 IST")
+	assert.False(st.T(), ss.withinWhitelist(outside), "08:00 IST should not be within 02:00-03:00 IST")
+}
+
+func (st *ShifterTestSuit) TestIfTimeIsWithinWLIntervalOfTheDay() {
+	st.configMock.On("SplitStringToSlice", config.ShifterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"02:00-03:00"})
+	st.configMock.On("GetStringMapString", config.ShifterWhiteListDayIntervalHours).Return(map[string]string{"Sunday": "02:00-03:00,10:00-18:00"})
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, nil)
+	ss.initWhitelist()
+
+	sunday, _ := time.Parse(time.RFC3339, "2020-06-21T12:00:00Z")
+	monday, _ := time.Parse(time.RFC3339, "2020-06-22T12:00:00Z")
+
+	assert.True(st.T(), ss.withinWhitelist(sunday), "12:00 should be within the Sunday intervals")
+	assert.False(st.T(), ss.withinWhitelist(monday), "12:00 should not be within the Monday intervals")
+}
+
+func (st *ShifterTestSuit) TestIfTimeIsWithinShiftWhiteListOfPolicy() {
+	f, err := ioutil.TempFile("", "application-*.yaml")
+	assert.Nil(st.T(), err)
+	defer os.Remove(f.Name())
+	f.WriteString("POLICIES:\n  - NAME: ingress\n    NODEPOOLS: [ingress-np-1]\n    SHIFT_WHITE_LIST_INTERVAL_HOURS: 02:00-03:00\n    SHIFT_WHITE_LIST_TIMEZONE: Asia/Kolkata\n")
+	f.Close()
+	policies, err := policy.Load(config.Init(f.Name()), nil)
+	assert.Nil(st.T(), err)
+	ingress, _ := policies.Get("ingress")
+	defaultPolicy, _ := policies.Get(policy.DefaultPolicyName)
+
+	start, _ := time.Parse(timeLayout, "12:00")
+	end, _ := time.Parse(timeLayout, "13:00")
+	ss := ShifterService{location: time.UTC}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		ss.whiteListIntervals[day] = []wlInterval{{start, end}}
+	}
+
+	night, _ := time.Parse(time.RFC3339, "2020-06-22T20:45:00Z")
+	noon, _ := time.Parse(time.RFC3339, "2020-06-22T12:30:00Z")
+
+	assert.True(st.T(), ss.withinShiftWhiteList(ingress, night), "02:15 IST should be within the shift whitelist of the policy")
+	assert.False(st.T(), ss.withinShiftWhiteList(ingress, noon), "18:00 IST should not be within the shift whitelist of the policy")
+	assert.False(st.T(), ss.withinShiftWhiteList(defaultPolicy, night), "20:45 UTC should not be within the whitelist of the Shifter")
+	assert.True(st.T(), ss.withinShiftWhiteList(defaultPolicy, noon), "12:30 UTC should be within the whitelist of the Shifter")
+	assert.True(st.T(), ss.withinAnyShiftWhiteList(policies, night))
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/roppenlabs/silent-assassin/pkg/calendar"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
 	"github.com/roppenlabs/silent-assassin/pkg/gcloud"
 	"github.com/roppenlabs/silent-assassin/pkg/k8s"
 	"github.com/roppenlabs/silent-assassin/pkg/killer"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
 	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/pause"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
+	"github.com/roppenlabs/silent-assassin/pkg/state"
 	container "google.golang.org/api/container/v1"
 	v1 "k8s.io/api/core/v1"
 )
@@ -31,53 +36,116 @@ type ShifterService struct {
 	gcloudClient       gcloud.IGCloudClient
 	killer             killer.IKiller
 	notifier           notifier.INotifierClient
-	whiteListIntervals []wlInterval
+	policySource       policy.ISource
+	pauser             pause.IPauser
+	whiteListIntervals [7][]wlInterval
+	location           *time.Location
+	configChanged      chan struct{}
+	status             *statusHolder
 }
 
-func NewShifterService(cp config.IProvider, zl logger.IZapLogger, kc k8s.IKubernetesClient, gc gcloud.IGCloudClient, nf notifier.INotifierClient, kl killer.IKiller) ShifterService {
-	return ShifterService{
-		cp:           cp,
-		logger:       zl,
-		kubeClient:   kc,
-		gcloudClient: gc,
-		notifier:     nf,
-		killer:       kl,
+func NewShifterService(cp config.IProvider, zl logger.IZapLogger, kc k8s.IKubernetesClient, gc gcloud.IGCloudClient, nf notifier.INotifierClient, kl killer.IKiller, ps policy.ISource, pa pause.IPauser) ShifterService {
+	ss := ShifterService{
+		cp:            cp,
+		logger:        zl,
+		kubeClient:    kc,
+		gcloudClient:  gc,
+		notifier:      nf,
+		killer:        kl,
+		policySource:  ps,
+		pauser:        pa,
+		configChanged: make(chan struct{}, 1),
+		status:        &statusHolder{},
+	}
+	cp.OnChange(ss.onConfigChange)
+	return ss
+}
+
+//onConfigChange makes the shifter load its whitelist again before its next poll.
+func (ss ShifterService) onConfigChange() {
+	select {
+	case ss.configChanged <- struct{}{}:
+	default:
 	}
 }
 
 func (ss ShifterService) Start(ctx context.Context, wg *sync.WaitGroup) {
 	ss.logger.Info(fmt.Sprintf("Starting Shifter Loop - Poll Interval: %d", ss.cp.GetInt(config.ShifterPollIntervalMs)))
 	ss.initWhitelist()
+	ss.status.update(func(s *Status) { s.Running = true })
 
 	for {
 		select {
 		case <-ctx.Done():
 			ss.logger.Info("Shutting down Shifter service")
+			ss.status.update(func(s *Status) { s.Running, s.NextPoll = false, nil })
 			wg.Done()
 			return
+		case <-ss.configChanged:
+			ss.logger.Info("Shifter: Loading the whitelist of the changed configuration")
+			if err := ss.loadWhitelist(); err != nil {
+				ss.logger.Error(fmt.Sprintf("Shifter: Error loading WhiteList Reason: %v", err))
+				ss.notifier.Error(config.EventConfig, fmt.Sprintf("Shifter: Error loading WhiteList %s", err.Error()))
+			}
 		default:
-			// Check if current time is in bettween whiteListIntervals. If yes, run ss.shift().
-			t := time.Now().UTC()
-			hour := fmt.Sprintf("%02d", t.Hour())
-			mins := fmt.Sprintf("%02d", t.Minute())
-			fmtTime := fmt.Sprintf("%s:%s", hour, mins)
-			now, err := time.Parse(timeLayout, fmtTime)
+			paused := ss.pauser != nil && ss.pauser.Paused("Shifter")
+			ss.status.update(func(s *Status) { s.Paused = paused })
 
+			// Check if current time is in bettween whiteListIntervals, of the Shifter or of a policy, not a blackout date
+			// and the disruptions are not paused. If yes, run ss.shift().
+			now := time.Now().In(ss.location)
+			policies, err := policy.Load(ss.cp, ss.policySource)
 			if err != nil {
-				panic(err)
-			}
-
-			for _, interval := range ss.whiteListIntervals {
-				if timeWithinWLIntervalCheck(interval.start, interval.end, now) {
-					ss.shift()
-					ss.logger.Info(fmt.Sprintf("Shifter sleeping for %v ms", ss.cp.GetInt(config.ShifterPollIntervalMs)))
-					time.Sleep(time.Millisecond * time.Duration(ss.cp.GetInt(config.ShifterPollIntervalMs)))
+				ss.logger.Error(fmt.Sprintf("Shifter: Error loading policies %s", err.Error()))
+				ss.notifier.Error(config.EventGetNodes, fmt.Sprintf("Shifter: Error loading policies %s", err.Error()))
+				ss.setPollStatus(now, false, false, err)
+			} else if ss.withinWhitelist(now) || ss.withinAnyShiftWhiteList(policies, now) {
+				blackout, err := calendar.LoadBlackout(ss.cp, ss.kubeClient)
+				if err != nil {
+					ss.logger.Error(fmt.Sprintf("Shifter: Error loading blackout dates %s", err.Error()))
+					ss.notifier.Error(config.EventBlackout, fmt.Sprintf("Shifter: Error loading blackout dates %s", err.Error()))
+					ss.setPollStatus(now, true, false, err)
+				} else if blackout.Contains(now) {
+					ss.logger.Info(fmt.Sprintf("Shifter: %s is a blackout date, not shifting", now.Format("2006-01-02")))
+					ss.setPollStatus(now, true, true, nil)
+				} else if paused {
+					ss.setPollStatus(now, true, false, nil)
+					ss.resume(ctx, true, policies, now)
+					ss.pauser.Defer("Shifter", "the shift of the node pools")
+				} else {
+					ss.setPollStatus(now, true, false, nil)
+					ss.resume(ctx, false, policies, now)
+					ss.shift(ctx, policies, now)
 				}
+			} else {
+				ss.setPollStatus(now, false, false, nil)
+			}
+			sleep := time.Millisecond * time.Duration(ss.cp.GetInt(config.ShifterPollIntervalMs))
+			nextPoll := time.Now().Add(sleep)
+			ss.status.update(func(s *Status) { s.NextPoll = &nextPoll })
+			ss.logger.Info(fmt.Sprintf("Shifter sleeping for %v ms", ss.cp.GetInt(config.ShifterPollIntervalMs)))
+			select {
+			case <-ctx.Done():
+			case <-time.After(sleep):
 			}
 		}
 	}
 }
 
+//setPollStatus records the outcome of the checks of a poll in the status, before any node is shifted.
+func (ss ShifterService) setPollStatus(now time.Time, withinWhitelist, blackout bool, err error) {
+	ss.status.update(func(s *Status) {
+		s.LastPoll = &now
+		s.NextPoll = nil
+		s.WithinWhitelist = withinWhitelist
+		s.Blackout = blackout
+		s.LastError = ""
+		if err != nil {
+			s.LastError = err.Error()
+		}
+	})
+}
+
 //getNodePoolMap finds out fallback on-demand nodePools and their respective preemptible node-pools.
 func (ss *ShifterService) getNodePoolMap() (map[string]npShiftConf, error) {
 	nodePoolMap := make(map[string]npShiftConf)
@@ -130,7 +198,7 @@ func (ss ShifterService) getNodePoolSize(selector string) (int64, error) {
 	nodeZoneWise := make(map[string]int64)
 
 	for _, node := range nodes.Items {
-		zone := node.ObjectMeta.Labels["failure-domain.beta.kubernetes.io/zone"]
+		zone := node.ObjectMeta.Labels[config.ZoneLabel]
 		if _, ok := nodeZoneWise[zone]; ok {
 			nodeZoneWise[zone]++
 		} else {
@@ -166,7 +234,181 @@ func (ss ShifterService) makeNodeUnschedulable(nodes []v1.Node) error {
 	return nil
 }
 
-func (ss ShifterService) shift() {
+func (ss ShifterService) makeNodeSchedulable(nodes []v1.Node) error {
+
+	for _, node := range nodes {
+		ss.logger.Info(fmt.Sprintf("Uncordoning node %v", node.Name))
+		recentNodeObject, err := ss.kubeClient.GetNode(node.Name)
+		if err != nil {
+			return err
+		}
+		recentNodeObject.Spec.Unschedulable = false
+		err = ss.kubeClient.UpdateNode(recentNodeObject)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//stopShift uncordons the nodes which were cordoned but not shifted when the disruptions got paused,
+//and records them as deferred until the disruptions are resumed.
+func (ss ShifterService) stopShift(nodes []v1.Node) {
+	ss.logger.Info(fmt.Sprintf("Shifter: disruptions paused, stopping the shift with %d nodes left", len(nodes)))
+	ss.status.update(func(s *Status) { s.Paused = true })
+	ss.uncordonNodesLeft(nodes)
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Name
+	}
+	ss.pauser.Defer("Shifter", "the shift of", names...)
+}
+
+//rollbackFailedDrain records the failed drain of the node in its silent-assassin/drain-failures annotation,
+//removes its state and uncordons it, so it is not resumed and is shifted again like any other node.
+func (ss ShifterService) rollbackFailedDrain(node v1.Node) error {
+	node, err := ss.kubeClient.GetNode(node.Name)
+	if err != nil {
+		return err
+	}
+	failures, _ := strconv.Atoi(node.Annotations[config.DrainFailuresAnnotation])
+	failures++
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[config.DrainFailuresAnnotation] = strconv.Itoa(failures)
+	delete(node.Annotations, config.StateAnnotation)
+	node.Spec.Unschedulable = false
+	if err := ss.kubeClient.UpdateNode(node); err != nil {
+		return err
+	}
+	ss.logger.Info(fmt.Sprintf("Drain of node %v failed %d time(s), uncordoned it", node.Name, failures))
+	ss.notifier.Info(config.EventRollback, fmt.Sprintf("Drain of node %v failed %d time(s), uncordoned it", node.Name, failures))
+	return nil
+}
+
+//uncordonNodesLeft makes the nodes which were cordoned but not shifted schedulable again.
+func (ss ShifterService) uncordonNodesLeft(nodes []v1.Node) {
+	if err := ss.makeNodeSchedulable(nodes); err != nil {
+		ss.notifier.Error(config.EventCordon, fmt.Sprintf("Error uncordoning node %v", err.Error()))
+		ss.logger.Error(fmt.Sprintf("Error uncordoning node %v", err.Error()))
+	}
+}
+
+//shiftNode drains the node, deletes its instance and then its k8s node, carrying on from the state of the node.
+//The instance deletion is stored in the state of the node, so a restart in between carries on with the k8s node.
+//Once the context is done the drained node is left for the next leader to delete.
+func (ss ShifterService) shiftNode(ctx context.Context, node v1.Node) error {
+	ss.status.update(func(s *Status) { s.ShiftingNodePool, s.ShiftingNode = node.Labels[config.NodePoolNameLabel], node.Name })
+	defer ss.status.update(func(s *Status) { s.ShiftingNodePool, s.ShiftingNode = "", "" })
+
+	switch state.Of(node) {
+	case state.Drained, state.InstanceDeleted:
+		ss.logger.Info(fmt.Sprintf("Node %v is already in state %v", node.Name, state.Of(node)))
+	default:
+		ss.logger.Info(fmt.Sprintf("Shifter Draining node %v", node.Name))
+		err := ss.killer.EvacuatePodsFromNode(node.Name, ss.cp.GetUint32(config.KillerDrainingTimeoutWhenNodeExpiredMs), false)
+		if err != nil {
+			ss.logger.Error(fmt.Sprintf("Error draining the node %v: %v", node.Name, err.Error()))
+			ss.notifier.Error(config.EventDrain, fmt.Sprintf("Error draining the node %v: %v", node.Name, err.Error()))
+			if err := ss.rollbackFailedDrain(node); err != nil {
+				ss.logger.Error(fmt.Sprintf("Error rolling back the drain of node %v: %v", node.Name, err.Error()))
+				ss.notifier.Error(config.EventRollback, fmt.Sprintf("Error rolling back the drain of node %v: %v", node.Name, err.Error()))
+			}
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		ss.logger.Info(fmt.Sprintf("Shifter stopped before deleting the drained node %v", node.Name))
+		return ctx.Err()
+	}
+
+	if state.Of(node) != state.InstanceDeleted {
+		// The instance is deleted through its managed instance group, which shrinks the on-demand node-pool.
+		ss.logger.Info(fmt.Sprintf("Deleting the instance %v", node.Name))
+		err := ss.gcloudClient.DeleteInstance(node.Labels[config.ZoneLabel], node.Name)
+		if err != nil {
+			ss.logger.Error(fmt.Sprintf("Error deleting the instance %v: %v", node.Name, err.Error()))
+			ss.notifier.Error(config.EventDeleteInstance, fmt.Sprintf("Error deleting the instance %v: %v", node.Name, err.Error()))
+			return err
+		}
+		ss.notifier.Info(config.EventDeleteInstance, fmt.Sprintf("Deleted the instance %v", node.Name))
+		if err := state.Set(ss.kubeClient, node.Name, state.InstanceDeleted); err != nil {
+			ss.logger.Warn(fmt.Sprintf("Error setting the state of node %v to %v: %v", node.Name, state.InstanceDeleted, err.Error()))
+		}
+	}
+
+	ss.logger.Info(fmt.Sprintf("Deleting the node %v", node.Name))
+	ss.notifier.Info(config.EventDeleteNode, fmt.Sprintf("Deleting the node %v", node.Name))
+	err := ss.kubeClient.DeleteNode(node.Name)
+	if err != nil {
+		ss.logger.Error(fmt.Sprintf("Error deleting the node %v: %v", node.Name, err.Error()))
+		ss.notifier.Error(config.EventDeleteNode, fmt.Sprintf("Error deleting the node %v: %v", node.Name, err.Error()))
+		return err
+	}
+	ss.status.update(func(s *Status) { s.ShiftedNodes++ })
+	return nil
+}
+
+//resume carries on the shift of the nodes of the on-demand node-pools which were in flight, after a restart or
+//a change of leader, when their shift whitelist allows it at now. While the disruptions are paused these nodes
+//are only deferred.
+func (ss ShifterService) resume(ctx context.Context, paused bool, policies policy.Policies, now time.Time) {
+	nodePoolMap, err := ss.getNodePoolMap()
+	if err != nil {
+		ss.logger.Error(fmt.Sprintf("Error creating the nodepool map: %v", err.Error()))
+		return
+	}
+
+	for onDemandNodePool := range nodePoolMap {
+		nodes, err := ss.kubeClient.GetNodes(fmt.Sprintf("%s=%s", config.NodePoolNameLabel, onDemandNodePool))
+		if err != nil {
+			ss.logger.Error(fmt.Sprintf("Error getting nodes in %v nodepool, %v", onDemandNodePool, err.Error()))
+			continue
+		}
+		for _, node := range ss.filterOutsideShiftWhiteList(nodes.Items, policies, now) {
+			if !state.InFlight(node) || ctx.Err() != nil {
+				continue
+			}
+			if paused {
+				ss.pauser.Defer("Shifter", "the shift of", node.Name)
+				continue
+			}
+			ss.logger.Info(fmt.Sprintf("Resuming the shift of node %v in state %v", node.Name, state.Of(node)))
+			ss.shiftNode(ctx, node)
+		}
+	}
+}
+
+//filterOutsideShiftWhiteList leaves out the nodes whose policy does not allow them to be shifted at t.
+func (ss ShifterService) filterOutsideShiftWhiteList(nodes []v1.Node, policies policy.Policies, t time.Time) []v1.Node {
+	filteredNodes := []v1.Node{}
+	for _, node := range nodes {
+		if !ss.withinShiftWhiteList(policies.ForNode(node), t) {
+			ss.logger.Debug(fmt.Sprintf("Node %s is outside the shift whitelist of its policy", node.Name))
+			continue
+		}
+		filteredNodes = append(filteredNodes, node)
+	}
+	return filteredNodes
+}
+
+//filterSkippedNodes leaves out the nodes annotated with silent-assassin/skip-shift, they are neither cordoned nor drained.
+func (ss ShifterService) filterSkippedNodes(nodes []v1.Node) []v1.Node {
+	filteredNodes := []v1.Node{}
+	for _, node := range nodes {
+		if node.Annotations[config.SkipShiftAnnotation] == "true" {
+			ss.logger.Info(fmt.Sprintf("Skipping node %s annotated with %s", node.Name, config.SkipShiftAnnotation))
+			continue
+		}
+		filteredNodes = append(filteredNodes, node)
+	}
+	return filteredNodes
+}
+
+//shift shifts the nodes of the on-demand node-pools whose policy allows them to be shifted at now.
+//It stops before the next node once the context is done.
+func (ss ShifterService) shift(ctx context.Context, policies policy.Policies, now time.Time) {
 	numberofZones := ss.gcloudClient.GetNumberOfZones()
 	//Create a nodepool map to determine source fallback on-demand nodepool and
 	//their respective preemptible preemptible nodepools.
@@ -204,6 +446,9 @@ func (ss ShifterService) shift() {
 				continue
 			}
 
+			onDemandNodes.Items = ss.filterSkippedNodes(onDemandNodes.Items)
+			onDemandNodes.Items = ss.filterOutsideShiftWhiteList(onDemandNodes.Items, policies, now)
+
 			// Cordon all source nodes so that no deleted workload will get scheduled in the source nodes again.
 			err = ss.makeNodeUnschedulable(onDemandNodes.Items)
 			if err != nil {
@@ -213,7 +458,20 @@ func (ss ShifterService) shift() {
 			}
 			nodesDeleted := 0
 			// Iterate through source nodes and drain the node.
-			for _, node := range onDemandNodes.Items {
+			for i, node := range onDemandNodes.Items {
+
+				// Once the lease is lost no new node is shifted, the next leader shifts the nodes left.
+				if ctx.Err() != nil {
+					ss.logger.Info(fmt.Sprintf("Shifter stopped, stopping the shift with %d nodes left", len(onDemandNodes.Items[i:])))
+					ss.uncordonNodesLeft(onDemandNodes.Items[i:])
+					return
+				}
+
+				// A pause stops the shift before the next node, the nodes left are made schedulable again.
+				if ss.pauser != nil && ss.pauser.Paused("Shifter") {
+					ss.stopShift(onDemandNodes.Items[i:])
+					return
+				}
 
 				if nodesDeleted%numberofZones == 0 {
 
@@ -235,28 +493,20 @@ func (ss ShifterService) shift() {
 						return
 					}
 				}
-				ss.logger.Info(fmt.Sprintf("Shifter Draining node %v", node.Name))
-				err := ss.killer.EvacuatePodsFromNode(node.Name, ss.cp.GetUint32(config.KillerDrainingTimeoutWhenNodeExpiredMs), false)
-
-				if err != nil {
-					ss.logger.Error(fmt.Sprintf("Error draining the node %v: %v", node.Name, err.Error()))
-					ss.notifier.Error(config.EventDrain, fmt.Sprintf("Error draining the node %v: %v", node.Name, err.Error()))
-					continue
-				}
-
-				ss.logger.Info(fmt.Sprintf("Deleting the node %v", node.Name))
-				ss.notifier.Info(config.EventDeleteNode, fmt.Sprintf("Deleting the node %v", node.Name))
-				err = ss.kubeClient.DeleteNode(node.Name)
-				if err != nil {
-					ss.logger.Error(fmt.Sprintf("Error deleting the node %v: %v", node.Name, err.Error()))
-					ss.notifier.Error(config.EventDeleteNode, fmt.Sprintf("Error deleting the node %v: %v", node.Name, err.Error()))
+				// Every notification about the node goes to the channel of its policy.
+				nodeShifter := ss
+				nodeShifter.notifier = ss.notifier.Route(policies.ForNode(node).SlackChannel)
+				if err := nodeShifter.shiftNode(ctx, node); err != nil {
 					continue
 				}
 				nodesDeleted++
 
 				//Sleep after node deletion for the workloads to stabilize
 				ss.logger.Info(fmt.Sprintf("Shifter sleeping for %d ms", ss.cp.GetInt32(config.ShifterSleepAfterNodeDeletionMs)))
-				time.Sleep(time.Millisecond * time.Duration(ss.cp.GetInt32(config.ShifterSleepAfterNodeDeletionMs)))
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Millisecond * time.Duration(ss.cp.GetInt32(config.ShifterSleepAfterNodeDeletionMs))):
+				}
 			}
 		}
 	}
@@ -1,14 +1,20 @@
 package shifter
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/disruption"
 	"github.com/roppenlabs/silent-assassin/pkg/gcloud"
 	"github.com/roppenlabs/silent-assassin/pkg/k8s"
 	"github.com/roppenlabs/silent-assassin/pkg/killer"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
 	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -17,6 +23,21 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+//pauserStub gets paused after its Paused method returned false running times, and records the deferred items.
+type pauserStub struct {
+	running  int
+	deferred []string
+}
+
+func (p *pauserStub) Paused(component string) bool {
+	p.running--
+	return p.running < 0
+}
+
+func (p *pauserStub) Defer(component, action string, items ...string) {
+	p.deferred = append(p.deferred, items...)
+}
+
 type ShifterTestSuit struct {
 	suite.Suite
 	configMock   *config.ProviderMock
@@ -35,7 +56,10 @@ func (st *ShifterTestSuit) SetupTest() {
 	st.notifierMock = new(notifier.NotifierClientMock)
 	st.notifierMock.On("Info", mock.Anything, mock.Anything)
 	st.notifierMock.On("Error", mock.Anything, mock.Anything)
+	st.configMock.On("GetString", config.ShifterWhiteListTimezone).Return("UTC")
 	st.configMock.On("GetString", mock.Anything).Return("debug")
+	st.configMock.On("GetInt", config.DisruptionMaxConcurrentDrains).Return(0)
+	st.configMock.On("GetInt", config.DisruptionMaxConcurrentDrainsPerNodePool).Return(0)
 	st.logger = logger.Init(st.configMock)
 	nodePools := []*container.NodePool{
 		{
@@ -143,7 +167,7 @@ func (st *ShifterTestSuit) TestShouldReturnRightNodePoolSize() {
 	st.k8sMock.On("GetNodes", "cloud.google.com/gke-nodepool=nodepool-1").Return(&v1.NodeList{Items: nodesEquallyDistributed}, nil)
 	st.k8sMock.On("GetNodes", "cloud.google.com/gke-nodepool=nodepool-2").Return(&v1.NodeList{Items: nodeInEquallyDistributed}, nil)
 
-	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock)
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, nil)
 
 	size, err := ss.getNodePoolSize("cloud.google.com/gke-nodepool=nodepool-1")
 
@@ -158,8 +182,8 @@ func (st *ShifterTestSuit) TestShouldReturnRightNodePoolSize() {
 
 func (st *ShifterTestSuit) TestShouldReturnRightNodePoolMaps() {
 
-	kl := killer.NewKillerService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock)
-	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, kl)
+	kl := killer.NewKillerService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, disruption.NewCoordinator(st.configMock, st.logger), nil, nil)
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, kl, nil, nil)
 
 	nodePoolMap, err := ss.getNodePoolMap()
 	assert.Nil(st.T(), err)
@@ -177,7 +201,7 @@ func (st *ShifterTestSuit) TestShouldReturnRightNodePoolMaps() {
 
 func (st *ShifterTestSuit) TestShouldShiftNodes() {
 
-	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock)
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, nil)
 
 	// st.k8sMock.AssertExpectations()
 	st.gCloudMock.On("GetNumberOfZones").Return(3)
@@ -273,6 +297,7 @@ func (st *ShifterTestSuit) TestShouldShiftNodes() {
 	st.configMock.On("GetUint32", config.KillerDrainingTimeoutWhenNodeExpiredMs).Return(uint32(1000))
 	st.configMock.On("GetInt32", config.ShifterSleepAfterNodeDeletionMs).Return(int32(1000))
 	st.gCloudMock.On("SetNodePoolSize", "services-p-1", int64(2), 10).Return(nil)
+	st.gCloudMock.On("DeleteInstance", mock.Anything, mock.Anything).Return(nil)
 
 	for _, node := range onDemandNodes.Items {
 
@@ -280,14 +305,27 @@ func (st *ShifterTestSuit) TestShouldShiftNodes() {
 		node.Spec.Unschedulable = true
 		st.k8sMock.On("UpdateNode", node).Return(nil)
 	}
+	st.k8sMock.On("UpdateNode", mock.MatchedBy(func(node v1.Node) bool {
+		return node.Annotations["silent-assassin/state"] == "instance-deleted"
+	})).Return(nil)
 	st.killerMock.On("EvacuatePodsFromNode", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	ss.shift()
+	// The nodes match no policy with a shift whitelist, so the whitelist of the Shifter applies.
+	ss.location = time.UTC
+	start, _ := time.Parse(timeLayout, "00:00")
+	end, _ := time.Parse(timeLayout, "23:59")
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		ss.whiteListIntervals[day] = []wlInterval{{start, end}}
+	}
+
+	ss.shift(context.Background(), policy.Policies{}, time.Now())
 
 	st.gCloudMock.AssertNumberOfCalls(st.T(), "ListNodePools", 1)
 	st.k8sMock.AssertNumberOfCalls(st.T(), "GetNodes", 4)
-	st.k8sMock.AssertNumberOfCalls(st.T(), "GetNode", 4)
-	st.k8sMock.AssertNumberOfCalls(st.T(), "UpdateNode", 4)
+	// Each node is got and updated once to cordon it and once to store the deletion of its instance.
+	st.k8sMock.AssertNumberOfCalls(st.T(), "GetNode", 8)
+	st.k8sMock.AssertNumberOfCalls(st.T(), "UpdateNode", 8)
+	st.gCloudMock.AssertCalled(st.T(), "DeleteInstance", "asia-south1-a", "node-np-1-1")
 
 	st.k8sMock.AssertExpectations(st.T())
 	st.gCloudMock.AssertExpectations(st.T())
@@ -295,6 +333,179 @@ func (st *ShifterTestSuit) TestShouldShiftNodes() {
 
 }
 
+func (st *ShifterTestSuit) TestShouldNotShiftSkippedNodes() {
+	skippedNode := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Annotations: map[string]string{"silent-assassin/skip-shift": "true"}}}
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}}
+
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, nil)
+
+	assert.Equal(st.T(), []v1.Node{node}, ss.filterSkippedNodes([]v1.Node{skippedNode, node}))
+}
+
+func (st *ShifterTestSuit) TestShouldOnlyDeleteK8sNodeOfShiftedInstance() {
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Annotations: map[string]string{"silent-assassin/state": "instance-deleted"}}}
+	st.k8sMock.On("DeleteNode", "node-1").Return(nil)
+
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, nil)
+
+	assert.Nil(st.T(), ss.shiftNode(context.Background(), node))
+	st.killerMock.AssertNotCalled(st.T(), "EvacuatePodsFromNode", mock.Anything, mock.Anything, mock.Anything)
+	st.gCloudMock.AssertNotCalled(st.T(), "DeleteInstance", mock.Anything, mock.Anything)
+	assert.Equal(st.T(), Status{ShiftedNodes: 1}, ss.Status())
+}
+
+func (st *ShifterTestSuit) TestShouldDeleteInstanceOfDrainedNodeWithoutDrainingIt() {
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        "node-1",
+		Labels:      map[string]string{"failure-domain.beta.kubernetes.io/zone": "asia-south1-a"},
+		Annotations: map[string]string{"silent-assassin/state": "drained"}}}
+	st.gCloudMock.On("DeleteInstance", "asia-south1-a", "node-1").Return(nil)
+	st.k8sMock.On("GetNode", "node-1").Return(node, nil)
+	st.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	st.k8sMock.On("DeleteNode", "node-1").Return(nil)
+
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, nil)
+
+	assert.Nil(st.T(), ss.shiftNode(context.Background(), node))
+	st.killerMock.AssertNotCalled(st.T(), "EvacuatePodsFromNode", mock.Anything, mock.Anything, mock.Anything)
+	st.k8sMock.AssertExpectations(st.T())
+	st.gCloudMock.AssertCalled(st.T(), "DeleteInstance", "asia-south1-a", "node-1")
+}
+
+func (st *ShifterTestSuit) TestShouldStopShiftAndUncordonNodesLeftWhenPaused() {
+	pauser := &pauserStub{running: 1}
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, pauser)
+
+	st.gCloudMock.On("GetNumberOfZones").Return(3)
+	onDemandNodes := v1.NodeList{}
+	for _, name := range []string{"node-np-1-1", "node-np-1-2", "node-np-1-3"} {
+		onDemandNodes.Items = append(onDemandNodes.Items, v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"failure-domain.beta.kubernetes.io/zone": "asia-south1-a",
+				"cloud.google.com/gke-nodepool":          "services-np-1",
+			},
+		}})
+	}
+	st.k8sMock.On("GetNodes", "cloud.google.com/gke-nodepool=services-np-1").Return(&onDemandNodes, nil)
+	st.k8sMock.On("GetNodes", "cloud.google.com/gke-nodepool=services-p-1").Return(&v1.NodeList{}, nil)
+	for _, node := range onDemandNodes.Items {
+		st.k8sMock.On("GetNode", node.Name).Return(node, nil)
+	}
+	st.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	st.k8sMock.On("DeleteNode", mock.Anything).Return(nil)
+	st.configMock.On("GetInt", config.ShifterNPResizeTimeout).Return(10)
+	st.configMock.On("GetUint32", config.KillerDrainingTimeoutWhenNodeExpiredMs).Return(uint32(1000))
+	st.configMock.On("GetInt32", config.ShifterSleepAfterNodeDeletionMs).Return(int32(0))
+	st.gCloudMock.On("SetNodePoolSize", "services-p-1", int64(1), 10).Return(nil)
+	st.gCloudMock.On("DeleteInstance", mock.Anything, mock.Anything).Return(nil)
+	st.killerMock.On("EvacuatePodsFromNode", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	st.allDayWhiteList(&ss)
+
+	ss.shift(context.Background(), policy.Policies{}, time.Now())
+
+	st.killerMock.AssertNumberOfCalls(st.T(), "EvacuatePodsFromNode", 1)
+	st.killerMock.AssertCalled(st.T(), "EvacuatePodsFromNode", "node-np-1-1", mock.Anything, mock.Anything)
+	assert.Equal(st.T(), []string{"node-np-1-2", "node-np-1-3"}, pauser.deferred)
+	assert.True(st.T(), ss.Status().Paused)
+
+	// Every node is cordoned, the state of the shifted node is stored and the nodes left are uncordoned.
+	updated := []string{}
+	for _, call := range st.k8sMock.Calls {
+		if node, ok := call.Arguments.Get(0).(v1.Node); ok && call.Method == "UpdateNode" && node.Annotations[config.StateAnnotation] == "" {
+			updated = append(updated, fmt.Sprintf("%s:%t", node.Name, node.Spec.Unschedulable))
+		}
+	}
+	assert.Equal(st.T(), []string{"node-np-1-1:true", "node-np-1-2:true", "node-np-1-3:true", "node-np-1-2:false", "node-np-1-3:false"}, updated)
+}
+
+func (st *ShifterTestSuit) TestShouldStopShiftAndUncordonNodesOnceTheLeaseIsLost() {
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, nil)
+
+	st.gCloudMock.On("GetNumberOfZones").Return(3)
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-np-1-1", Labels: map[string]string{"cloud.google.com/gke-nodepool": "services-np-1"}}}
+	st.k8sMock.On("GetNodes", "cloud.google.com/gke-nodepool=services-np-1").Return(&v1.NodeList{Items: []v1.Node{node, node}}, nil)
+	st.k8sMock.On("GetNode", node.Name).Return(node, nil)
+	st.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	st.allDayWhiteList(&ss)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ss.shift(ctx, policy.Policies{}, time.Now())
+
+	st.killerMock.AssertNotCalled(st.T(), "EvacuatePodsFromNode", mock.Anything, mock.Anything, mock.Anything)
+	st.gCloudMock.AssertNotCalled(st.T(), "SetNodePoolSize", mock.Anything, mock.Anything, mock.Anything)
+	last := st.k8sMock.Calls[len(st.k8sMock.Calls)-1].Arguments.Get(0).(v1.Node)
+	assert.False(st.T(), last.Spec.Unschedulable)
+}
+
+func (st *ShifterTestSuit) TestShouldDeferInFlightShiftsWhilePaused() {
+	pauser := &pauserStub{}
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        "node-np-1-1",
+		Labels:      map[string]string{"cloud.google.com/gke-nodepool": "services-np-1"},
+		Annotations: map[string]string{"silent-assassin/state": "draining"}}}
+	st.k8sMock.On("GetNodes", mock.Anything).Return(&v1.NodeList{Items: []v1.Node{node}}, nil)
+
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, pauser)
+	st.allDayWhiteList(&ss)
+	ss.resume(context.Background(), true, policy.Policies{}, time.Now())
+
+	assert.Equal(st.T(), []string{"node-np-1-1"}, pauser.deferred)
+	st.killerMock.AssertNotCalled(st.T(), "EvacuatePodsFromNode", mock.Anything, mock.Anything, mock.Anything)
+	st.gCloudMock.AssertNotCalled(st.T(), "DeleteInstance", mock.Anything, mock.Anything)
+	st.k8sMock.AssertNotCalled(st.T(), "DeleteNode", mock.Anything)
+}
+
+func (st *ShifterTestSuit) TestShouldUncordonAndResetNodeWhoseShiftDrainFailed() {
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", Annotations: map[string]string{"silent-assassin/state": "draining"}},
+		Spec:       v1.NodeSpec{Unschedulable: true}}
+	st.k8sMock.On("GetNode", "node-1").Return(node, nil)
+	st.k8sMock.On("UpdateNode", mock.Anything).Return(nil)
+	st.configMock.On("GetUint32", config.KillerDrainingTimeoutWhenNodeExpiredMs).Return(uint32(1000))
+	st.killerMock.On("EvacuatePodsFromNode", "node-1", mock.Anything, false).Return(errors.New("PDB"))
+
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, nil)
+
+	assert.Error(st.T(), ss.shiftNode(context.Background(), node))
+	updated := st.k8sMock.Calls[len(st.k8sMock.Calls)-1].Arguments.Get(0).(v1.Node)
+	assert.False(st.T(), updated.Spec.Unschedulable)
+	assert.Equal(st.T(), map[string]string{"silent-assassin/drain-failures": "1"}, updated.Annotations)
+	st.gCloudMock.AssertNotCalled(st.T(), "DeleteInstance", mock.Anything, mock.Anything)
+}
+
+func (st *ShifterTestSuit) TestShouldNotResumeShiftOutsideTheWhiteList() {
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        "node-np-1-1",
+		Labels:      map[string]string{"cloud.google.com/gke-nodepool": "services-np-1"},
+		Annotations: map[string]string{"silent-assassin/state": "drained"}}}
+	st.k8sMock.On("GetNodes", mock.Anything).Return(&v1.NodeList{Items: []v1.Node{node}}, nil)
+
+	ss := NewShifterService(st.configMock, st.logger, st.k8sMock, st.gCloudMock, st.notifierMock, st.killerMock, nil, nil)
+	ss.location = time.UTC
+	start, _ := time.Parse(timeLayout, "12:00")
+	end, _ := time.Parse(timeLayout, "13:00")
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		ss.whiteListIntervals[day] = []wlInterval{{start, end}}
+	}
+
+	ss.resume(context.Background(), false, policy.Policies{}, time.Date(2020, 6, 20, 3, 0, 0, 0, time.UTC))
+	st.gCloudMock.AssertNotCalled(st.T(), "DeleteInstance", mock.Anything, mock.Anything)
+	st.k8sMock.AssertNotCalled(st.T(), "DeleteNode", mock.Anything)
+}
+
+//allDayWhiteList makes the whitelist of the Shifter span every day.
+func (st *ShifterTestSuit) allDayWhiteList(ss *ShifterService) {
+	ss.location = time.UTC
+	start, _ := time.Parse(timeLayout, "00:00")
+	end, _ := time.Parse(timeLayout, "23:59")
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		ss.whiteListIntervals[day] = []wlInterval{{start, end}}
+	}
+}
+
 func TestShiftererTestSuite(t *testing.T) {
 	suite.Run(t, new(ShifterTestSuit))
 }
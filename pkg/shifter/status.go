@@ -0,0 +1,52 @@
+package shifter
+
+import (
+	"sync"
+	"time"
+)
+
+//Status is what the Shifter of this replica is doing, served by the API of the server.
+type Status struct {
+	//Running is false on the replicas which are not the leader, their Shifter does not poll.
+	Running          bool       `json:"running"`
+	WithinWhitelist  bool       `json:"withinWhitelist"`
+	Blackout         bool       `json:"blackout"`
+	Paused           bool       `json:"paused"`
+	LastPoll         *time.Time `json:"lastPoll,omitempty"`
+	NextPoll         *time.Time `json:"nextPoll,omitempty"`
+	ShiftingNodePool string     `json:"shiftingNodePool,omitempty"`
+	ShiftingNode     string     `json:"shiftingNode,omitempty"`
+	ShiftedNodes     int        `json:"shiftedNodes"`
+	LastError        string     `json:"lastError,omitempty"`
+}
+
+//IStatusReporter reports the Status of the Shifter.
+type IStatusReporter interface {
+	Status() Status
+}
+
+//statusHolder shares the Status between the copies of the ShifterService.
+type statusHolder struct {
+	sync.RWMutex
+	status Status
+}
+
+//update changes the status with f. It does nothing on a nil holder, like the one of a ShifterService literal.
+func (h *statusHolder) update(f func(s *Status)) {
+	if h == nil {
+		return
+	}
+	h.Lock()
+	defer h.Unlock()
+	f(&h.status)
+}
+
+//Status returns the current Status of the Shifter.
+func (ss ShifterService) Status() Status {
+	if ss.status == nil {
+		return Status{}
+	}
+	ss.status.RLock()
+	defer ss.status.RUnlock()
+	return ss.status.status
+}
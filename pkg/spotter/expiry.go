@@ -3,12 +3,13 @@ package spotter
 import (
 	"errors"
 	"fmt"
-	"math/rand"
 	"strings"
 	"time"
 
 	"github.com/google/go-intervals/timespanset"
+	"github.com/roppenlabs/silent-assassin/pkg/calendar"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -29,7 +30,6 @@ var (
 )
 
 func init() {
-	rand.Seed(time.Now().Unix())
 	var err error
 
 	// whitelistStart is the start of the day
@@ -46,95 +46,154 @@ func init() {
 	}
 }
 
+//whitelist holds the whitelist intervals of each day of the week, as spans within whitelistStart
+//and whitelistEnd, and the timezone in which their wall clock times are to be read.
+type whitelist struct {
+	days     [7]*timespanset.Set
+	location *time.Location
+}
+
+//span is a concrete whitelist interval on a given day.
+type span struct {
+	start time.Time
+	end   time.Time
+}
+
+//initWhitelist loads the policies and parses the whitelist intervals of each of them, it panics if any is invalid.
 func (ss *spotterService) initWhitelist() {
-	ss.whiteListIntervals = timespanset.Empty()
-	wlStr := ss.cp.SplitStringToSlice(config.SpotterWhiteListIntervalHours, config.CommaSeparater)
+	if err := ss.loadWhitelists(); err != nil {
+		ss.logger.Error(fmt.Sprintf("Spotter: Error loading policies Reason: %v", err))
+		panic(err)
+	}
+}
+
+//loadWhitelists loads the policies and parses the whitelist intervals of each of them.
+func (ss *spotterService) loadWhitelists() error {
+	policies, whitelists, err := readWhitelists(ss.cp, ss.policySource)
+	if err != nil {
+		return err
+	}
+	for name, wl := range whitelists {
+		ss.logger.Debug(fmt.Sprintf("Spotter: Whitelist set initialized for policy %s : %v %v", name, wl.days, wl.location))
+	}
+	ss.policies = policies
+	ss.whitelists = whitelists
+	return nil
+}
+
+//readWhitelists loads the policies and parses the whitelist intervals of each of them, by policy name.
+func readWhitelists(cp config.IProvider, src policy.ISource) (policy.Policies, map[string]whitelist, error) {
+	whitelists := make(map[string]whitelist)
+	policies, err := policy.Load(cp, src)
+	if err != nil {
+		return policies, whitelists, err
+	}
+
+	for _, p := range policies.All() {
+		wl := whitelist{location: p.Location()}
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			whiteListIntervals, err := parseWhitelist(p.WhiteListIntervalsOn(day))
+			if err != nil {
+				return policies, whitelists, fmt.Errorf("error parsing the whitelist of policy %s on %s: %v", p.Name, day, err)
+			}
+			wl.days[day] = whiteListIntervals
+		}
+		whitelists[p.Name] = wl
+	}
+	return policies, whitelists, nil
+}
+
+//parseWhitelist parses the intervals in HH:MM-HH:MM format into a set of spans within whitelistStart and whitelistEnd.
+func parseWhitelist(wlStr []string) (*timespanset.Set, error) {
+	whiteListIntervals := timespanset.Empty()
 	for _, wl := range wlStr {
 		times := strings.Split(wl, "-")
+		if len(times) != 2 {
+			return whiteListIntervals, fmt.Errorf("invalid interval %s, expected HH:MM-HH:MM", wl)
+		}
 		start, err := time.Parse(time.RFC3339, whitelistStartPrefix+times[0]+whitelistTimePostfix)
 		if err != nil {
-			ss.logger.Error(fmt.Sprintf("Spotter: Error parsing WhiteList Start date Reason: %v", err))
-			panic(err)
+			return whiteListIntervals, err
 		}
 		end, err := time.Parse(time.RFC3339, whitelistStartPrefix+times[1]+whitelistTimePostfix)
 		if err != nil {
-			ss.logger.Error(fmt.Sprintf("Spotter: Error parsing WhiteList End date Reason: %v", err))
-			panic(err)
+			return whiteListIntervals, err
 		}
 		if end.Before(start) {
-			ss.whiteListIntervals.Insert(start, whitelistEnd)
+			whiteListIntervals.Insert(start, whitelistEnd)
 			start = whitelistStart
 		}
-		ss.whiteListIntervals.Insert(start, end)
+		whiteListIntervals.Insert(start, end)
 	}
-	ss.logger.Info(fmt.Sprintf("Spotter: Whitelist set initialized : %v", ss.whiteListIntervals))
+	return whiteListIntervals, nil
 }
 
-// midnight returns the midnight for the date
-func midnight(t time.Time) time.Time {
-	year, month, day := t.Date()
-	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+//onDay returns the wall clock time of the day in the location, at the offset of t from whitelistStart.
+//Building the time from the wall clock keeps it right across daylight saving transitions.
+func onDay(day time.Time, t time.Time, location *time.Location) time.Time {
+	offset := t.Sub(whitelistStart)
+	year, month, date := day.Date()
+	return time.Date(year, month, date, int(offset.Hours()), int(offset.Minutes())%60, 0, 0, location)
 }
 
-func randomNumber(a, b int) int {
-	return a + rand.Intn(b-a+1)
-}
+//intervalsWithin returns the whitelist intervals, on the days in the timezone of the whitelist,
+//that lie strictly within from and to. The days that are blacked out have no intervals.
+func (wl whitelist) intervalsWithin(from, to time.Time, blackout calendar.Blackout) []span {
+	spans := make([]span, 0)
+	firstDay := midnight(from.In(wl.location)).AddDate(0, 0, -1)
+	lastDay := midnight(to.In(wl.location)).AddDate(0, 0, 1)
 
-func randomMinuntes(t1, t2 time.Time) time.Duration {
-	interval := t2.Sub(t1).Minutes()
-	randMins := randomNumber(0, int(interval))
-	return time.Duration(randMins)
+	for day := firstDay; !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		if blackout.Contains(day) {
+			continue
+		}
+		wl.days[day.Weekday()].IntervalsBetween(whitelistStart, whitelistEnd, func(start, end time.Time) bool {
+			s := span{start: onDay(day, start, wl.location), end: onDay(day, end, wl.location)}
+			if from.Before(s.start) && s.end.Before(to) {
+				spans = append(spans, s)
+			}
+			return true
+		})
+	}
+	return spans
 }
 
-func (ss *spotterService) getExpiryTimestamp(node v1.Node) (string, error) {
-
-	creationTsUTC := node.GetCreationTimestamp().Time.UTC()
-
-	ss.logger.Debug(fmt.Sprintf("GetExpiryTime : Node = %v Created time = [ %v ] Created Time in UTC = [ %v ]", node.Name, node.GetCreationTimestamp(), creationTsUTC))
-
-	truncatedCT := midnight(creationTsUTC)
-	projectedCT := whitelistStart.Add(creationTsUTC.Sub(truncatedCT))
-
-	actualExpiry := creationTsUTC.Add(24 * time.Hour)
-
-	truncatedET := midnight(actualExpiry)
-	projectedET := whitelistStart.Add(actualExpiry.Sub(truncatedET)).Add(24 * time.Hour)
+// midnight returns the midnight for the date
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
 
-	ss.logger.Debug(fmt.Sprintf("GetExpiryTime : Node = %v Projected CT = [ %v ] Projected ExpiryTime = [ %v ]", node.Name, projectedCT, projectedET))
-	eligibleExpiryTimes := make([]time.Time, 0)
-	for day := 0; day < 2; day++ {
+//getExpiryTimestamp returns the expiry time of the node in the least loaded slot of the schedule
+//within the whitelist intervals of its policy, before the node reaches its 24 hours limit.
+func (ss *spotterService) getExpiryTimestamp(node v1.Node, nodePolicy policy.Policy, blackout calendar.Blackout, sched schedule) (string, error) {
 
-		ss.whiteListIntervals.IntervalsBetween(whitelistStart, whitelistEnd, func(start, end time.Time) bool {
-			if day == 1 {
-				start = start.Add(24 * time.Hour)
-				end = end.Add(24 * time.Hour)
-			}
-			ss.logger.Debug(fmt.Sprintf("GetExpiryTime : [Current Interval] Node = %v Day = %d, start = [ %v ], end = [ %v ], elegibleWLIntervals = [ %v ]", node.Name, day, start, end, eligibleExpiryTimes))
-			if projectedCT.Before(start) && end.Before(projectedET) {
-				timeToBeAdded := randomMinuntes(start, end)
-				eligibleExpiryTimes = append(eligibleExpiryTimes, start.Add(time.Duration(timeToBeAdded)*time.Minute))
+	wl, ok := ss.whitelists[nodePolicy.Name]
+	if !ok {
+		return "", fmt.Errorf("No whitelist found for policy %s", nodePolicy.Name)
+	}
 
-				ss.logger.Debug(fmt.Sprintf("GetExpiryTime : [Eligible Interval] Node = %v Day = %d, start = [ %v ], end = [ %v ], elegibleWLIntervals = [ %v ]", node.Name, day, start, end, eligibleExpiryTimes))
-			}
+	creationTs := node.GetCreationTimestamp().Time
+	actualExpiry := creationTs.Add(24 * time.Hour)
 
-			return true
-		})
+	ss.logger.Debug(fmt.Sprintf("GetExpiryTime : Node = %v Created time = [ %v ] Actual ExpiryTime = [ %v ] Timezone = %v", node.Name, creationTs, actualExpiry, wl.location))
 
-	}
+	eligibleIntervals := wl.intervalsWithin(creationTs, actualExpiry, blackout)
+	ss.logger.Debug(fmt.Sprintf("GetExpiryTime : [Eligible Intervals] Node = %v elegibleWLIntervals = [ %v ]", node.Name, eligibleIntervals))
 
-	if len(eligibleExpiryTimes) == 0 {
+	if len(eligibleIntervals) == 0 {
 		return "", errors.New("Cannot find a date")
 	}
 
-	saExpirtyTime := eligibleExpiryTimes[rand.Intn(len(eligibleExpiryTimes))]
-
-	ss.logger.Debug(fmt.Sprintf("GetExpiryTime : Node = %v elegibleWLIntervals = %v, saExpirtyTime = [ %v ]", node.Name, eligibleExpiryTimes, saExpirtyTime))
-	finalexp := midnight(creationTsUTC).Add(saExpirtyTime.Sub(whitelistStart))
+	saExpirtyTime, err := sched.assign(eligibleIntervals, node)
+	if err != nil {
+		return "", err
+	}
 
-	if finalexp.After(actualExpiry) {
+	if saExpirtyTime.After(actualExpiry) {
 		return "", errors.New("The Expiry time we calculated is after Actual Expiry Time :facepalm")
 	}
-	finalexp = finalexp.In(node.GetCreationTimestamp().Time.Location())
+	finalexp := saExpirtyTime.In(creationTs.Location())
 	ss.logger.Debug(fmt.Sprintf("GetExpiryTime : Node = %v Final Expiry = [ %v ]", node.Name, finalexp))
 	return finalexp.Format(time.RFC1123Z), nil
 }
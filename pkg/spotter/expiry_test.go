@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/roppenlabs/silent-assassin/pkg/calendar"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -93,7 +96,8 @@ func (suite *SpotterTestSuite) TestShouldSlotNodeExpTimeToOneOfElegibleWLInRando
 	}
 
 	suite.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"00:00-06:00", "12:00-14:00"})
-	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock)
+	suite.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil)
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
 	ss.initWhitelist()
 
 	for _, testInput := range testData {
@@ -106,7 +110,7 @@ func (suite *SpotterTestSuite) TestShouldSlotNodeExpTimeToOneOfElegibleWLInRando
 				CreationTimestamp: metav1.NewTime(creationTimestamp),
 				Annotations:       map[string]string{"node.alpha.kubernetes.io/ttl": "0"}}}
 
-		saExpTimeString, _ := ss.getExpiryTimestamp(nodeToBeAnnotated)
+		saExpTimeString, _ := ss.getExpiryTimestamp(nodeToBeAnnotated, ss.policies.ForNode(nodeToBeAnnotated), calendar.Blackout{}, newSchedule(10, 0, 0, config.NodePoolNameLabel, nil))
 		saExpTime, _ := time.Parse(time.RFC1123Z, saExpTimeString)
 
 		assert.True(suite.T(), verifyNodeExpiry(saExpTime, testInput.EligibleWLs), fmt.Sprintf("SA_Expiry time =[ %v ] didn't fall within one of the eligible WL interval = [ %v ] for Node = %v", saExpTime, testInput.EligibleWLs, testInput.NodeName))
@@ -116,7 +120,8 @@ func (suite *SpotterTestSuite) TestShouldSlotNodeExpTimeToOneOfElegibleWLInRando
 func (suite *SpotterTestSuite) TestShouldReturnETinSameTimeZoneAsCT() {
 
 	suite.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"00:00-06:00", "12:00-14:00"})
-	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock)
+	suite.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil)
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
 	ss.initWhitelist()
 	creationTime := parseTime("Mon, 22 Jun 2020 22:20:00 +0530")
 	nodeToBeAnnotated := v1.Node{
@@ -124,28 +129,58 @@ func (suite *SpotterTestSuite) TestShouldReturnETinSameTimeZoneAsCT() {
 			Name:              "Node-IST",
 			CreationTimestamp: metav1.NewTime(creationTime),
 			Annotations:       map[string]string{"node.alpha.kubernetes.io/ttl": "0"}}}
-	saExpTimeString, _ := ss.getExpiryTimestamp(nodeToBeAnnotated)
+	saExpTimeString, _ := ss.getExpiryTimestamp(nodeToBeAnnotated, ss.policies.ForNode(nodeToBeAnnotated), calendar.Blackout{}, newSchedule(10, 0, 0, config.NodePoolNameLabel, nil))
 	saExpTime, _ := time.Parse(time.RFC1123Z, saExpTimeString)
 
 	assert.True(suite.T(), saExpTime.Location() == creationTime.Location(), "CT and ET TimeZone does not match")
 
 }
 
-func (suite *SpotterTestSuite) TestRandomNumber() {
-	n1 := randomNumber(10, 30)
-	n2 := randomNumber(10, 30)
+//Whitelist intervals are wall clock times in the timezone of the policy, also on the day clocks move forward
+func (suite *SpotterTestSuite) TestShouldSlotNodeExpTimeInWLOfPolicyTimezoneAcrossDST() {
+	suite.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"00:00-06:00"})
+	suite.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		policies := args.Get(1).(*[]policy.Policy)
+		*policies = []policy.Policy{{Name: "berlin", WhiteListIntervalHours: "04:00-05:00", WhiteListTimezone: "Europe/Berlin"}}
+	})
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
+	ss.initWhitelist()
 
-	assert.True(suite.T(), n1 >= 10, fmt.Sprintf("Expected >10 got %d", n1))
-	assert.True(suite.T(), n1 <= 30, fmt.Sprintf("Expected <30 got %d", n1))
-	assert.True(suite.T(), n2 >= 10, fmt.Sprintf("Expected >10 got %d", n2))
-	assert.True(suite.T(), n2 <= 30, fmt.Sprintf("Expected <30 got %d", n2))
+	//Clocks in Berlin move from 02:00 CET to 03:00 CEST on 29 Mar 2020, 04:00-05:00 CEST is 02:00-03:00 UTC
+	nodeToBeAnnotated := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "Node-Berlin",
+			CreationTimestamp: metav1.NewTime(parseTime("Sat, 28 Mar 2020 06:00:00 +0000"))}}
+	eligibleWLs := []TimeSpan{{Start: parseTime("Sun, 29 Mar 2020 02:00:00 +0000"), End: parseTime("Sun, 29 Mar 2020 03:00:00 +0000")}}
+
+	saExpTimeString, err := ss.getExpiryTimestamp(nodeToBeAnnotated, ss.policies.ForNode(nodeToBeAnnotated), calendar.Blackout{}, newSchedule(10, 0, 0, config.NodePoolNameLabel, nil))
+	assert.Nil(suite.T(), err)
+	saExpTime := parseTime(saExpTimeString)
+	assert.True(suite.T(), verifyNodeExpiry(saExpTime, eligibleWLs), fmt.Sprintf("SA_Expiry time =[ %v ] didn't fall within the eligible WL interval = [ %v ]", saExpTime, eligibleWLs))
 }
 
-func (suite *SpotterTestSuite) TestRandomMins() {
-	t1, _ := time.Parse(time.RFC1123Z, "Mon, 22 Jun 2020 09:00:00 +0000")
-	t2, _ := time.Parse(time.RFC1123Z, "Mon, 22 Jun 2020 09:45:00 +0000")
+//Day of the week rules replace the whitelist intervals on their days, blackout dates have no intervals
+func (suite *SpotterTestSuite) TestShouldSlotNodeExpTimeInWLOfTheDayOutsideBlackout() {
+	suite.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"00:00-06:00"})
+	suite.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		policies := args.Get(1).(*[]policy.Policy)
+		*policies = []policy.Policy{{Name: "weekend", WhiteListIntervalHours: "00:00-06:00,12:00-14:00", WhiteListDayIntervalHours: map[string]string{"saturday": "00:00-02:00"}}}
+	})
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
+	ss.initWhitelist()
+
+	//Created on Friday, 19 Jun 2020 is blacked out so only the Saturday interval is eligible
+	nodeToBeAnnotated := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "Node-Weekend",
+			CreationTimestamp: metav1.NewTime(parseTime("Fri, 19 Jun 2020 08:00:00 +0000"))}}
+	eligibleWLs := []TimeSpan{{Start: parseTime("Sat, 20 Jun 2020 00:00:00 +0000"), End: parseTime("Sat, 20 Jun 2020 02:00:00 +0000")}}
+
+	saExpTimeString, err := ss.getExpiryTimestamp(nodeToBeAnnotated, ss.policies.ForNode(nodeToBeAnnotated), calendar.Blackout{"2020-06-19": true}, newSchedule(10, 0, 0, config.NodePoolNameLabel, nil))
+	assert.Nil(suite.T(), err)
+	saExpTime := parseTime(saExpTimeString)
+	assert.True(suite.T(), verifyNodeExpiry(saExpTime, eligibleWLs), fmt.Sprintf("SA_Expiry time =[ %v ] didn't fall within the eligible WL interval = [ %v ]", saExpTime, eligibleWLs))
 
-	randMins := randomMinuntes(t1, t2)
-	assert.True(suite.T(), randMins >= 0*time.Minute, fmt.Sprintf("randMins must be >=0 but is: %d ", randMins))
-	assert.True(suite.T(), randMins <= 45*time.Minute, fmt.Sprintf("randMins must be <= 45 but is: %d ", randMins))
+	_, err = ss.getExpiryTimestamp(nodeToBeAnnotated, ss.policies.ForNode(nodeToBeAnnotated), calendar.Blackout{"2020-06-19": true, "2020-06-20": true}, newSchedule(10, 0, 0, config.NodePoolNameLabel, nil))
+	assert.NotNil(suite.T(), err, "No expiry time should be found when every day is blacked out")
 }
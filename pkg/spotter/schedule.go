@@ -0,0 +1,124 @@
+package spotter
+
+import (
+	"errors"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+//defaultSlotMinutes is the length of a slot when SpotterSlotMinutes is not set.
+const defaultSlotMinutes = 10
+
+//schedule counts the expiry times set on the nodes in slots of a fixed length, in total, per nodepool and per zone,
+//so new nodes can be given the least loaded slot. A maximum of 0 means no limit. The nodepool of a node is read
+//from the nodePoolLabel label, the GKE nodepool label when empty.
+type schedule struct {
+	slotMinutes    int
+	maxPerNodePool int
+	maxPerZone     int
+	nodePoolLabel  string
+	total          map[int64]int
+	nodePools      map[int64]map[string]int
+	zones          map[int64]map[string]int
+}
+
+func newSchedule(slotMinutes, maxPerNodePool, maxPerZone int, nodePoolLabel string, nodes []v1.Node) schedule {
+	if slotMinutes <= 0 {
+		slotMinutes = defaultSlotMinutes
+	}
+	if nodePoolLabel == "" {
+		nodePoolLabel = config.NodePoolNameLabel
+	}
+	s := schedule{
+		slotMinutes:    slotMinutes,
+		maxPerNodePool: maxPerNodePool,
+		maxPerZone:     maxPerZone,
+		nodePoolLabel:  nodePoolLabel,
+		total:          make(map[int64]int),
+		nodePools:      make(map[int64]map[string]int),
+		zones:          make(map[int64]map[string]int),
+	}
+	for _, node := range nodes {
+		s.addNode(node)
+	}
+	return s
+}
+
+//addNode counts the expiry time annotated on the node, nodes without a valid one are ignored.
+func (s schedule) addNode(node v1.Node) {
+	expiryTime, err := time.Parse(time.RFC1123Z, node.Annotations[config.ExpiryTimeAnnotation])
+	if err != nil {
+		return
+	}
+
+	slot := s.slotOf(expiryTime)
+	s.total[slot]++
+	if s.nodePools[slot] == nil {
+		s.nodePools[slot] = make(map[string]int)
+		s.zones[slot] = make(map[string]int)
+	}
+	s.nodePools[slot][node.Labels[s.nodePoolLabel]]++
+	s.zones[slot][node.Labels[config.ZoneLabel]]++
+}
+
+func (s schedule) slotOf(t time.Time) int64 {
+	return t.Unix() / int64(s.slotMinutes*60)
+}
+
+func (s schedule) slotStart(slot int64) time.Time {
+	return time.Unix(slot*int64(s.slotMinutes*60), 0)
+}
+
+//hasCapacity returns true if one more node of the nodepool and zone can expire in the slot.
+func (s schedule) hasCapacity(slot int64, nodePool, zone string) bool {
+	if s.maxPerNodePool > 0 && s.nodePools[slot][nodePool] >= s.maxPerNodePool {
+		return false
+	}
+	if s.maxPerZone > 0 && s.zones[slot][zone] >= s.maxPerZone {
+		return false
+	}
+	return true
+}
+
+//assign returns an expiry time for the node in the least loaded slot of the spans, which are in time order,
+//the earliest one on a tie.
+//Nodes sharing a slot are set a minute apart. It fails if every slot is at the limit of the nodepool or zone of the node.
+func (s schedule) assign(spans []span, node v1.Node) (time.Time, error) {
+	nodePool := node.Labels[s.nodePoolLabel]
+	zone := node.Labels[config.ZoneLabel]
+
+	var expiryTime time.Time
+	found := false
+	load := 0
+	for _, sp := range spans {
+		for slot := s.slotOf(sp.start); slot <= s.slotOf(sp.end); slot++ {
+			if !s.hasCapacity(slot, nodePool, zone) {
+				continue
+			}
+			if found && s.total[slot] >= load {
+				continue
+			}
+
+			//The part of the slot within the span, the end of the slot being the start of the next one
+			start, end := s.slotStart(slot), s.slotStart(slot+1).Add(-time.Minute)
+			if start.Before(sp.start) {
+				start = sp.start
+			}
+			if end.After(sp.end) {
+				end = sp.end
+			}
+			if end.Before(start) {
+				continue
+			}
+			offset := s.total[slot] % (int(end.Sub(start).Minutes()) + 1)
+			expiryTime, load, found = start.Add(time.Duration(offset)*time.Minute), s.total[slot], true
+		}
+	}
+
+	if !found {
+		return expiryTime, errors.New("Every slot of the eligible intervals is at the limit of kills of the nodepool or zone")
+	}
+	return expiryTime, nil
+}
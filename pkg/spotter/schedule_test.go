@@ -0,0 +1,88 @@
+package spotter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func scheduledNode(name, nodePool, zone, expiryTime string) v1.Node {
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   name,
+		Labels: map[string]string{config.NodePoolNameLabel: nodePool, config.ZoneLabel: zone}}}
+	if expiryTime != "" {
+		node.Annotations = map[string]string{config.ExpiryTimeAnnotation: expiryTime}
+	}
+	return node
+}
+
+func (suite *SpotterTestSuite) TestShouldAssignLeastLoadedSlot() {
+	existing := []v1.Node{
+		scheduledNode("node-1", "services-p-1", "asia-south1-a", "Mon, 22 Jun 2020 12:00:00 +0000"),
+		scheduledNode("node-2", "services-p-1", "asia-south1-b", "Mon, 22 Jun 2020 12:05:00 +0000"),
+		scheduledNode("node-3", "services-p-1", "asia-south1-c", "Mon, 22 Jun 2020 12:10:00 +0000"),
+	}
+	sched := newSchedule(10, 0, 0, config.NodePoolNameLabel, existing)
+	spans := []span{{start: parseTime("Mon, 22 Jun 2020 12:00:00 +0000"), end: parseTime("Mon, 22 Jun 2020 12:30:00 +0000")}}
+
+	expiryTime, err := sched.assign(spans, scheduledNode("node-4", "services-p-1", "asia-south1-a", ""))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "12:20", expiryTime.UTC().Format("15:04"), "The empty 12:20 slot should be assigned")
+}
+
+func (suite *SpotterTestSuite) TestShouldSpreadNodesAcrossSlots() {
+	sched := newSchedule(10, 0, 0, config.NodePoolNameLabel, nil)
+	spans := []span{{start: parseTime("Mon, 22 Jun 2020 12:00:00 +0000"), end: parseTime("Mon, 22 Jun 2020 12:29:00 +0000")}}
+
+	expiryTimes := []string{}
+	for i := 0; i < 4; i++ {
+		node := scheduledNode(fmt.Sprintf("node-%d", i), "services-p-1", "asia-south1-a", "")
+		expiryTime, err := sched.assign(spans, node)
+		assert.Nil(suite.T(), err)
+		node.Annotations = map[string]string{config.ExpiryTimeAnnotation: expiryTime.Format(time.RFC1123Z)}
+		sched.addNode(node)
+		expiryTimes = append(expiryTimes, expiryTime.UTC().Format("15:04"))
+	}
+
+	assert.Equal(suite.T(), []string{"12:00", "12:10", "12:20", "12:01"}, expiryTimes)
+}
+
+func (suite *SpotterTestSuite) TestShouldEnforceKillsPerSlotPerNodePoolAndZone() {
+	existing := []v1.Node{
+		scheduledNode("node-1", "services-p-1", "asia-south1-a", "Mon, 22 Jun 2020 12:00:00 +0000"),
+		scheduledNode("node-2", "ingress-p-1", "asia-south1-b", "Mon, 22 Jun 2020 12:10:00 +0000"),
+	}
+	sched := newSchedule(10, 1, 1, config.NodePoolNameLabel, existing)
+	spans := []span{{start: parseTime("Mon, 22 Jun 2020 12:00:00 +0000"), end: parseTime("Mon, 22 Jun 2020 12:19:00 +0000")}}
+
+	expiryTime, err := sched.assign(spans, scheduledNode("node-3", "ingress-p-1", "asia-south1-a", ""))
+	assert.NotNil(suite.T(), err, fmt.Sprintf("12:00 is full for the zone and 12:10 for the nodepool, got %v", expiryTime))
+
+	expiryTime, err = sched.assign(spans, scheduledNode("node-4", "services-p-1", "asia-south1-b", ""))
+	assert.NotNil(suite.T(), err, fmt.Sprintf("12:00 is full for the nodepool and 12:10 for the zone, got %v", expiryTime))
+
+	expiryTime, err = sched.assign(spans, scheduledNode("node-5", "services-p-1", "asia-south1-c", ""))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "12:11", expiryTime.UTC().Format("15:04"))
+}
+
+func (suite *SpotterTestSuite) TestShouldCountNodePoolsWithConfiguredLabel() {
+	existing := scheduledNode("node-1", "services-p-1", "asia-south1-a", "Mon, 22 Jun 2020 12:00:00 +0000")
+	existing.Labels["example.com/pool"] = "services"
+	sched := newSchedule(10, 1, 0, "example.com/pool", []v1.Node{existing})
+	spans := []span{{start: parseTime("Mon, 22 Jun 2020 12:00:00 +0000"), end: parseTime("Mon, 22 Jun 2020 12:09:00 +0000")}}
+
+	// Both nodes are in the same GKE nodepool, but the configured label tells their nodepools apart.
+	node := scheduledNode("node-2", "services-p-1", "asia-south1-b", "")
+	node.Labels["example.com/pool"] = "batch"
+	_, err := sched.assign(spans, node)
+	assert.Nil(suite.T(), err)
+
+	node.Labels["example.com/pool"] = "services"
+	_, err = sched.assign(spans, node)
+	assert.NotNil(suite.T(), err)
+}
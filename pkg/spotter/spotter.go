@@ -6,28 +6,57 @@ import (
 	"sync"
 	"time"
 
-	"github.com/google/go-intervals/timespanset"
+	"github.com/roppenlabs/silent-assassin/pkg/calendar"
 	"github.com/roppenlabs/silent-assassin/pkg/config"
 	"github.com/roppenlabs/silent-assassin/pkg/k8s"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
 	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
+	"github.com/roppenlabs/silent-assassin/pkg/state"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 type spotterService struct {
-	cp                 config.IProvider
-	logger             logger.IZapLogger
-	kubeClient         k8s.IKubernetesClient
-	whiteListIntervals *timespanset.Set
-	notifier           notifier.INotifierClient
+	cp            config.IProvider
+	logger        logger.IZapLogger
+	kubeClient    k8s.IKubernetesClient
+	policySource  policy.ISource
+	policies      policy.Policies
+	whitelists    map[string]whitelist
+	notifier      notifier.INotifierClient
+	nodeAdded     chan struct{}
+	configChanged chan struct{}
 }
 
-func NewSpotterService(cp config.IProvider, zl logger.IZapLogger, kc k8s.IKubernetesClient, nf notifier.INotifierClient) spotterService {
-	return spotterService{
-		cp:         cp,
-		logger:     zl,
-		kubeClient: kc,
-		notifier:   nf,
+func NewSpotterService(cp config.IProvider, zl logger.IZapLogger, kc k8s.IKubernetesClient, nf notifier.INotifierClient, ps policy.ISource) spotterService {
+	ss := spotterService{
+		cp:            cp,
+		logger:        zl,
+		kubeClient:    kc,
+		policySource:  ps,
+		notifier:      nf,
+		nodeAdded:     make(chan struct{}, 1),
+		configChanged: make(chan struct{}, 1),
+	}
+	cp.OnChange(ss.onConfigChange)
+	return ss
+}
+
+//onConfigChange makes the spotter load the whitelists again before its next spot.
+func (ss spotterService) onConfigChange() {
+	select {
+	case ss.configChanged <- struct{}{}:
+	default:
+	}
+}
+
+//OnNodeAdd triggers a spot as soon as a node joins the cluster, instead of waiting for the next poll.
+//Triggers arriving while one is pending are coalesced into it.
+func (ss spotterService) OnNodeAdd(obj interface{}) {
+	select {
+	case ss.nodeAdded <- struct{}{}:
+	default:
 	}
 }
 
@@ -43,9 +72,17 @@ func (ss spotterService) Start(ctx context.Context, wg *sync.WaitGroup) {
 			ss.logger.Info("Shutting down spotter service")
 			wg.Done()
 			return
-		default:
+		case <-ss.configChanged:
+			ss.logger.Info("Spotter: Loading the whitelists of the changed configuration")
+			if err := ss.loadWhitelists(); err != nil {
+				ss.logger.Error(fmt.Sprintf("Spotter: Error loading policies Reason: %v", err))
+				ss.notifier.Error(config.EventConfig, fmt.Sprintf("Spotter: Error loading policies %s", err.Error()))
+			}
+		case <-ss.nodeAdded:
+			ss.logger.Debug("Spotting on node addition")
+			ss.spot()
+		case <-time.After(time.Millisecond * time.Duration(ss.cp.GetInt(config.SpotterPollIntervalMs))):
 			ss.spot()
-			time.Sleep(time.Millisecond * time.Duration(ss.cp.GetInt(config.SpotterPollIntervalMs)))
 		}
 	}
 }
@@ -53,11 +90,21 @@ func (ss spotterService) Start(ctx context.Context, wg *sync.WaitGroup) {
 func getNodeDetails(node v1.Node) string {
 	return fmt.Sprintf("Node: %s\n"+
 		"Creation Time: %s\n"+
-		"ExpiryTime: %s",
-		node.Name, node.CreationTimestamp, node.Annotations[config.ExpiryTimeAnnotation])
+		"ExpiryTime: %s\n"+
+		"Policy: %s",
+		node.Name, node.CreationTimestamp, node.Annotations[config.ExpiryTimeAnnotation], node.Annotations[config.PolicyAnnotation])
 }
 
 func (ss spotterService) spot() {
+	// The policy resources change at any time, so their whitelists are loaded again on every spot.
+	if ss.policySource != nil {
+		if err := ss.loadWhitelists(); err != nil {
+			ss.logger.Error(fmt.Sprintf("Error loading policies %s", err.Error()))
+			ss.notifier.Error(config.EventGetNodes, fmt.Sprintf("Error loading policies %s", err.Error()))
+			return
+		}
+	}
+
 	nodes, err := ss.kubeClient.GetNodes(ss.cp.GetString(config.NodeSelectors))
 
 	if err != nil {
@@ -67,36 +114,65 @@ func (ss spotterService) spot() {
 	}
 	ss.logger.Debug(fmt.Sprintf("Fetched %d node(s)", len(nodes.Items)))
 
+	blackout, err := calendar.LoadBlackout(ss.cp, ss.kubeClient)
+	if err != nil {
+		ss.logger.Error(fmt.Sprintf("Error loading blackout dates %s", err.Error()))
+		ss.notifier.Error(config.EventBlackout, fmt.Sprintf("Error loading blackout dates %s", err.Error()))
+		return
+	}
+
+	sched := newSchedule(ss.cp.GetInt(config.SpotterSlotMinutes), ss.cp.GetInt(config.SpotterMaxKillsPerSlotPerNodePool), ss.cp.GetInt(config.SpotterMaxKillsPerSlotPerZone), ss.cp.GetString(config.NodePoolLabel), nodes.Items)
+
 	for _, node := range nodes.Items {
 		nodeAnnotations := node.GetAnnotations()
 
 		if _, ok := nodeAnnotations[config.ExpiryTimeAnnotation]; ok {
 			continue
 		}
+		if nodeAnnotations[config.SkipExpiryAnnotation] == "true" {
+			ss.logger.Debug(fmt.Sprintf("Skipping node %s annotated with %s", node.Name, config.SkipExpiryAnnotation))
+			continue
+		}
 		if nodeAnnotations == nil {
 			nodeAnnotations = make(map[string]string, 0)
 		}
 
 		nodeDetails := getNodeDetails(node)
-		expiryTime, err := ss.getExpiryTimestamp(node)
+		nodePolicy := ss.policies.ForNode(node)
+		nf := ss.notifier.Route(nodePolicy.SlackChannel)
+		expiryTime, err := ss.getExpiryTimestamp(node, nodePolicy, blackout, sched)
 		if err != nil {
 			ss.logger.Error(fmt.Sprintf("Coluld not get expiry time %s", err.Error()))
-			ss.notifier.Error(config.EventAnnotate, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
+			nf.Error(config.EventAnnotate, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
+			if state.Of(node) != state.Spotted {
+				state.Annotate(&node, state.Spotted)
+				if err := ss.kubeClient.UpdateNode(node); err != nil {
+					ss.logger.Error(fmt.Sprintf("Failed to set the state of node %s to %s", node.Name, state.Spotted))
+				}
+			}
 			continue
 		}
 		ss.logger.Debug(fmt.Sprintf("spot() : Node = %v Creation Time = [ %v ] Expirty Time [ %v ]", node.Name, node.GetCreationTimestamp(), expiryTime))
 		nodeAnnotations[config.ExpiryTimeAnnotation] = expiryTime
+		nodeAnnotations[config.PolicyAnnotation] = nodePolicy.Name
+		nodeAnnotations[config.StateAnnotation] = state.Scheduled
 
 		node.SetAnnotations(nodeAnnotations)
 		err = ss.kubeClient.UpdateNode(node)
+		if apierrors.IsConflict(err) {
+			// The node was read from the cache before its latest update landed in it, it is spotted again on the next spot.
+			ss.logger.Info(fmt.Sprintf("Node %s changed since it was read, skipping it until the next spot", node.ObjectMeta.Name))
+			continue
+		}
 		if err != nil {
 			ss.logger.Error(fmt.Sprintf("Failed to annotate node : %s", node.ObjectMeta.Name))
-			ss.notifier.Error(config.EventAnnotate, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
+			nf.Error(config.EventAnnotate, fmt.Sprintf("%s\nError:%s", nodeDetails, err.Error()))
 			continue
 		}
 
+		sched.addNode(node)
 		ss.logger.Info(fmt.Sprintf("Annotated node : %s", node.ObjectMeta.Name))
-		ss.notifier.Info(config.EventAnnotate, getNodeDetails(node))
+		nf.Info(config.EventAnnotate, getNodeDetails(node))
 
 	}
 
@@ -1,6 +1,7 @@
 package spotter
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -8,10 +9,13 @@ import (
 	"github.com/roppenlabs/silent-assassin/pkg/k8s"
 	"github.com/roppenlabs/silent-assassin/pkg/logger"
 	"github.com/roppenlabs/silent-assassin/pkg/notifier"
+	"github.com/roppenlabs/silent-assassin/pkg/policy"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -32,19 +36,30 @@ func (suit *SpotterTestSuite) SetupTest() {
 	suit.notifierMock.On("Error", mock.Anything, mock.Anything)
 	suit.configMock.On("GetString", config.NodeSelectors).Return("cloud.google.com/gke-preemptible=true,label2=test")
 	suit.configMock.On("GetString", config.LogLevel).Return("info")
+	suit.configMock.On("GetString", config.SpotterWhiteListTimezone).Return("")
+	suit.configMock.On("GetString", config.NodePoolLabel).Return(config.NodePoolNameLabel)
+	suit.configMock.On("GetStringMapString", config.SpotterWhiteListDayIntervalHours).Return(map[string]string{})
+	suit.configMock.On("GetStringSlice", config.BlackoutDates).Return([]string{})
+	suit.configMock.On("GetString", config.BlackoutFile).Return("")
+	suit.configMock.On("GetString", config.BlackoutConfigMap).Return("")
 	suit.configMock.On("GetInt", config.SpotterPollIntervalMs).Return(10)
+	suit.configMock.On("GetInt", config.SpotterSlotMinutes).Return(10)
+	suit.configMock.On("GetInt", config.SpotterMaxKillsPerSlotPerNodePool).Return(0)
+	suit.configMock.On("GetInt", config.SpotterMaxKillsPerSlotPerZone).Return(0)
 	suit.configMock.On("SplitStringToSlice", config.NodeSelectors, config.CommaSeparater).Return([]string{"cloud.google.com/gke-preemptible=true,label2=test"})
+	suit.configMock.On("GetUint32", config.KillerDrainingTimeoutWhenNodeExpiredMs).Return(uint32(300000))
 
 	suit.logger = logger.Init(suit.configMock)
 }
 
 func (suite *SpotterTestSuite) TestShouldFetchNodesWithLabels() {
+	suite.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil)
 
 	suite.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, ",").Return([]string{"00:00-06:00", "12:00-14:00"})
 	suite.configMock.On("GetString", config.NodeSelectors).Return("cloud.google.com/gke-preemptible=true,label2=test")
 	suite.k8sMock.On("GetNodes", "cloud.google.com/gke-preemptible=true,label2=test").Return(&v1.NodeList{}, nil)
 
-	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock)
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
 	ss.initWhitelist()
 
 	ss.spot()
@@ -59,6 +74,7 @@ func (suite *SpotterTestSuite) TestShouldAnnotateIfAbsent() {
 			Name:        "Node-1",
 			Annotations: map[string]string{"silent-assassin/expiry-time": time.Now().String()}}}
 	suite.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"00:00-06:00", "12:00-14:00"})
+	suite.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil)
 	nodeToBeAnnotated := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-2"}}
 
 	nodeList := v1.NodeList{
@@ -71,19 +87,86 @@ func (suite *SpotterTestSuite) TestShouldAnnotateIfAbsent() {
 		if !found {
 			return false
 		}
+		assert.Equal(suite.T(), "default", input.ObjectMeta.Annotations["silent-assassin/policy"], "Policy annotation is not matching")
 		assert.Equal(suite.T(), "Node-2", input.ObjectMeta.Name, "Node name is not matching")
 		return true
 
 	})).Return(nil)
 
 	suite.notifierMock.On("Info", "ANNOTATE", mock.Anything).Return(nil)
-	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock)
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
 	ss.initWhitelist()
 	ss.spot()
 
 	suite.k8sMock.AssertExpectations(suite.T())
 }
 
+func (suite *SpotterTestSuite) TestShouldAnnotateWithPolicyOfTheNode() {
+	suite.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"00:00-06:00"})
+	suite.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		policies := args.Get(1).(*[]policy.Policy)
+		*policies = []policy.Policy{{Name: "ingress", NodePools: []string{"ingress-p-1"}, WhiteListIntervalHours: "12:00-14:00"}}
+	})
+
+	nodeToBeAnnotated := v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:              "Node-1",
+		CreationTimestamp: metav1.NewTime(parseTime("Mon, 22 Jun 2020 00:40:00 +0000")),
+		Labels:            map[string]string{"cloud.google.com/gke-nodepool": "ingress-p-1"}}}
+	suite.k8sMock.On("GetNodes", mock.Anything).Return(&v1.NodeList{Items: []v1.Node{nodeToBeAnnotated}}, nil)
+	suite.k8sMock.On("UpdateNode", mock.MatchedBy(func(input v1.Node) bool {
+		expiryTime := parseTime(input.ObjectMeta.Annotations["silent-assassin/expiry-time"])
+		return input.ObjectMeta.Annotations["silent-assassin/policy"] == "ingress" &&
+			verifyNodeExpiry(expiryTime, []TimeSpan{{Start: parseTime("Mon, 22 Jun 2020 12:00:00 +0000"), End: parseTime("Mon, 22 Jun 2020 14:00:00 +0000")}})
+	})).Return(nil)
+
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
+	ss.initWhitelist()
+	ss.spot()
+
+	suite.k8sMock.AssertExpectations(suite.T())
+}
+
+func (suite *SpotterTestSuite) TestShouldNotAnnotateSkippedNode() {
+	suite.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"00:00-06:00"})
+	suite.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil)
+
+	skippedNode := v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        "Node-1",
+		Annotations: map[string]string{"silent-assassin/skip-expiry": "true"}}}
+	suite.k8sMock.On("GetNodes", mock.Anything).Return(&v1.NodeList{Items: []v1.Node{skippedNode}}, nil)
+
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
+	ss.initWhitelist()
+	ss.spot()
+
+	suite.k8sMock.AssertNotCalled(suite.T(), "UpdateNode", mock.Anything)
+}
+
+func (suite *SpotterTestSuite) TestShouldNotNotifyWhenTheCachedNodeIsStale() {
+	suite.configMock.On("SplitStringToSlice", config.SpotterWhiteListIntervalHours, config.CommaSeparater).Return([]string{"00:00-06:00"})
+	suite.configMock.On("UnmarshalKey", config.Policies, mock.Anything).Return(nil)
+
+	staleNode := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1", ResourceVersion: "1"}}
+	suite.k8sMock.On("GetNodes", mock.Anything).Return(&v1.NodeList{Items: []v1.Node{staleNode}}, nil)
+	suite.k8sMock.On("UpdateNode", mock.Anything).Return(apierrors.NewConflict(schema.GroupResource{Resource: "nodes"}, "Node-1", errors.New("the object has been modified")))
+
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
+	ss.initWhitelist()
+	ss.spot()
+
+	suite.k8sMock.AssertExpectations(suite.T())
+	suite.notifierMock.AssertNotCalled(suite.T(), "Error", config.EventAnnotate, mock.Anything)
+}
+
+func (suite *SpotterTestSuite) TestShouldCoalesceNodeAddTriggers() {
+	ss := NewSpotterService(suite.configMock, suite.logger, suite.k8sMock, suite.notifierMock, nil)
+
+	ss.OnNodeAdd(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-1"}})
+	ss.OnNodeAdd(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "Node-2"}})
+
+	assert.Equal(suite.T(), 1, len(ss.nodeAdded), "Node additions should be coalesced into one pending trigger")
+}
+
 func TestSpotterTestSuite(t *testing.T) {
 	suite.Run(t, new(SpotterTestSuite))
 }
@@ -0,0 +1,67 @@
+package state
+
+import (
+	"github.com/roppenlabs/silent-assassin/pkg/config"
+	"github.com/roppenlabs/silent-assassin/pkg/k8s"
+	v1 "k8s.io/api/core/v1"
+)
+
+//The states of a node in its lifecycle, stored in its silent-assassin/state annotation,
+//so the Killer and the Shifter pick up where they were after a restart or a change of leader.
+const (
+	//Spotted nodes were seen by the Spotter, which could not schedule their expiry yet.
+	Spotted = "spotted"
+
+	//Scheduled nodes have their expiry time set.
+	Scheduled = "scheduled"
+
+	//Draining nodes are cordoned and their pods are being evicted.
+	Draining = "draining"
+
+	//Drained nodes have no pods left and are about to be deleted.
+	Drained = "drained"
+
+	//InstanceDeleted nodes have their instance deleted, only their k8s node is left.
+	InstanceDeleted = "instance-deleted"
+
+	//NodeDeleted nodes are gone. The state is only reported, as there is no node left to store it on.
+	NodeDeleted = "node-deleted"
+
+	//Failed nodes could not be drained before their deadline.
+	Failed = "failed"
+)
+
+//Of returns the state of the node, empty if it has none.
+func Of(node v1.Node) string {
+	return node.Annotations[config.StateAnnotation]
+}
+
+//InFlight returns true if the node is being disrupted, so its disruption has to be carried on whatever its expiry time.
+func InFlight(node v1.Node) bool {
+	switch Of(node) {
+	case Draining, Drained, InstanceDeleted:
+		return true
+	}
+	return false
+}
+
+//Annotate sets the state on the node object, without updating it.
+func Annotate(node *v1.Node, state string) {
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[config.StateAnnotation] = state
+}
+
+//Set updates the state of the node, on its latest version.
+func Set(kc k8s.IKubernetesClient, name, state string) error {
+	node, err := kc.GetNode(name)
+	if err != nil {
+		return err
+	}
+	if Of(node) == state {
+		return nil
+	}
+	Annotate(&node, state)
+	return kc.UpdateNode(node)
+}